@@ -2,4 +2,7 @@ module modbSalesApp
 
 go 1.15
 
-require github.com/sijms/go-ora v0.0.0-20201230204601-9c6316265b76
+require (
+	github.com/mattn/go-sqlite3 v1.14.6
+	github.com/sijms/go-ora v0.0.0-20201230204601-9c6316265b76
+)
@@ -0,0 +1,160 @@
+package datasources
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"modbSalesApp/src/repositories"
+)
+
+const (
+	MotivInventar    = "inventar"
+	MotivDeteriorare = "deteriorare"
+	MotivReceptie    = "receptie"
+)
+
+// Articole is replicated on every site, so it is changed on all replicas in
+// one distributed transaction.
+
+func (client DBClient) InsertArticol(articol repositories.Articol, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		return forEachReplica(tx, connections, "Articole", func(client DBClient, q execer) error {
+			return client.insertArticol(q, articol)
+		})
+	})
+}
+
+func (client DBClient) EditArticol(articol repositories.Articol, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		return forEachReplica(tx, connections, "Articole", func(client DBClient, q execer) error {
+			return client.editArticol(q, articol)
+		})
+	})
+}
+
+// DeleteArticol deletes the article from every replica once no site holds a
+// sale line referencing it.
+func (client DBClient) DeleteArticol(codArticol string, connections Connections) error {
+	linii, err := countOnLocalSites(connections, func(client Store) (int, error) {
+		return client.CountLiniiVanzariArticol(codArticol)
+	})
+	if err != nil {
+		return err
+	}
+	if linii > 0 {
+		return fmt.Errorf("articol %s is used by %d linii de vanzare: %w", codArticol, linii, ErrReferenced)
+	}
+
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		return forEachReplica(tx, connections, "Articole", func(client DBClient, q execer) error {
+			return client.deleteArticol(q, codArticol)
+		})
+	})
+}
+
+func IsMotivMiscareStoc(motiv string) bool {
+	switch motiv {
+	case MotivInventar, MotivDeteriorare, MotivReceptie:
+		return true
+	default:
+		return false
+	}
+}
+
+// InsertMiscareStoc changes the stock of an article and records the movement
+// on global. Cantitate is the counted stock for an inventar and the quantity
+// lost or received otherwise. The stock is taken from global and written to
+// every replica, which also heals replicas that drifted apart.
+func (client DBClient) InsertMiscareStoc(miscare repositories.MiscareStoc, connections Connections) (repositories.MiscareStoc, error) {
+	if !IsMotivMiscareStoc(miscare.Motiv) {
+		return repositories.MiscareStoc{}, fmt.Errorf("unknown motiv %q", miscare.Motiv)
+	}
+
+	IDMiscare, err := nextID(miscariStocSequence)
+	if err != nil {
+		return repositories.MiscareStoc{}, err
+	}
+	miscare.IDMiscare = IDMiscare
+	miscare.Data = time.Now().Format("2006-01-02 15:04:05")
+
+	err = runDistributedTransaction(func(tx *distributedTransaction) error {
+		global, q, err := tx.enlist(connections[GlobalConnectionName])
+		if err != nil {
+			return err
+		}
+
+		cantitateStoc, err := global.getCantitateStoc(q, miscare.CodArticol)
+		if err != nil {
+			return err
+		}
+
+		switch miscare.Motiv {
+		case MotivInventar:
+			miscare.Diferenta = miscare.Cantitate - cantitateStoc
+		case MotivDeteriorare:
+			miscare.Diferenta = -miscare.Cantitate
+		case MotivReceptie:
+			miscare.Diferenta = miscare.Cantitate
+		}
+		miscare.CantitateStoc = cantitateStoc + miscare.Diferenta
+		if miscare.CantitateStoc < 0 {
+			return fmt.Errorf("articol %s has %d in stock, %d requested: %w", miscare.CodArticol, cantitateStoc, -miscare.Diferenta, ErrInsufficientStock)
+		}
+
+		err = forEachReplica(tx, connections, "Articole", func(client DBClient, q execer) error {
+			return client.addCantitateStoc(q, miscare.CodArticol, miscare.Diferenta)
+		})
+		if err != nil {
+			return err
+		}
+
+		return global.insertMiscareStoc(q, miscare)
+	})
+	if err != nil {
+		return repositories.MiscareStoc{}, err
+	}
+
+	return miscare, nil
+}
+
+// takeStocLinie takes the quantity of a new or changed line out of stock;
+// stock is kept in whole units.
+func takeStocLinie(tx *distributedTransaction, connections Connections, linie repositories.LinieVanzare) error {
+	if linie.Cantitate <= 0 || linie.Cantitate != float32(math.Trunc(float64(linie.Cantitate))) {
+		return fmt.Errorf("Cantitate %v is not a positive whole number: %w", linie.Cantitate, ErrInvalidCantitate)
+	}
+
+	return changeCantitateStoc(tx, connections, linie.CodArticol, -int(linie.Cantitate))
+}
+
+// restoreStocLinie puts the quantity of a saved line back in stock, unless the
+// line was saved without taking it.
+func restoreStocLinie(tx *distributedTransaction, connections Connections, linie repositories.LinieVanzare) error {
+	if !linie.StocScazut {
+		return nil
+	}
+
+	return changeCantitateStoc(tx, connections, linie.CodArticol, int(math.Round(float64(linie.Cantitate))))
+}
+
+// changeCantitateStoc checks diferenta against the stock on global, which
+// locks the article, and adds it to every replica.
+func changeCantitateStoc(tx *distributedTransaction, connections Connections, codArticol string, diferenta int) error {
+	global, q, err := tx.enlist(connections[GlobalConnectionName])
+	if err != nil {
+		return err
+	}
+
+	cantitateStoc, err := global.getCantitateStoc(q, codArticol)
+	if err != nil {
+		return err
+	}
+	if cantitateStoc+diferenta < 0 {
+		return fmt.Errorf("articol %s has %d in stock, %d requested: %w", codArticol, cantitateStoc, -diferenta, ErrInsufficientStock)
+	}
+
+	return forEachReplica(tx, connections, "Articole", func(client DBClient, q execer) error {
+		return client.addCantitateStoc(q, codArticol, diferenta)
+	})
+}
@@ -0,0 +1,335 @@
+package datasources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"modbSalesApp/src/repositories"
+)
+
+type fragmentRows struct {
+	keys []string
+	rows map[string]map[string]interface{}
+}
+
+// CheckConsistency compares every local fragment in fragmentationCatalog with
+// the projection and selection of the global table it is defined on. Tables
+// written only on the local sites are compared with the union of their
+// fragments instead. With withRepair, the report also holds the statements
+// that bring the fragment back in line.
+func CheckConsistency(connections Connections, withRepair bool) ([]repositories.FragmentConsistency, error) {
+	global, err := getSQLClient(connections[GlobalConnectionName])
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(fragmentationCatalog))
+	for table := range fragmentationCatalog {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var report []repositories.FragmentConsistency
+	for _, table := range tables {
+		var clients []DBClient
+		for _, connectionName := range connectionNames {
+			if _, ok := fragmentationCatalog[table].fragments[connectionName]; !ok {
+				continue
+			}
+
+			store, ok := connections[connectionName]
+			if !ok {
+				return nil, fmt.Errorf("no connection configured for site %s", connectionName)
+			}
+			client, err := getSQLClient(store)
+			if err != nil {
+				return nil, err
+			}
+			clients = append(clients, client)
+		}
+
+		for _, client := range clients {
+			var consistency repositories.FragmentConsistency
+			if fragmentationCatalog[table].localOnly {
+				consistency, err = checkLocalFragmentConsistency(clients, client, table, withRepair)
+			} else {
+				consistency, err = checkFragmentConsistency(global, client, table, withRepair)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not check %s on %s: %w", table, client.name, err)
+			}
+			report = append(report, consistency)
+		}
+	}
+
+	return report, nil
+}
+
+func IsConsistent(report []repositories.FragmentConsistency) bool {
+	for _, consistency := range report {
+		if len(consistency.RanduriLipsa) > 0 || len(consistency.RanduriInPlus) > 0 || len(consistency.Diferente) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func checkFragmentConsistency(global DBClient, client DBClient, table string, withRepair bool) (repositories.FragmentConsistency, error) {
+	key := fragmentationCatalog[table].key
+	columns := getFragmentColumns(table, client.name)
+
+	expectedQuery := getSelectStatement(table, columns, global.tableSuffix)
+	if predicate := getFragmentPredicate(table, client.name); len(predicate) > 0 {
+		expectedQuery = fmt.Sprintf("%s WHERE %s", expectedQuery, predicate)
+	}
+	expected, err := queryFragmentRows(global, expectedQuery, columns, key)
+	if err != nil {
+		return repositories.FragmentConsistency{}, err
+	}
+
+	actual, err := queryFragmentRows(client, getFragmentSelectStatement(table, client.name), columns, key)
+	if err != nil {
+		return repositories.FragmentConsistency{}, err
+	}
+
+	return compareFragmentRows(client, table, expected, actual, withRepair, func(string) bool {
+		return true
+	}), nil
+}
+
+// checkLocalFragmentConsistency checks a fragment of a table written only on
+// the local sites. The site should hold every row routed to it, wherever it is
+// found: its own rows first, then the ones misplaced on other sites. A row it
+// holds but is routed elsewhere is reported, and only deleted on repair when
+// the site it is routed to holds it too, so the last copy of a sale is never
+// removed.
+func checkLocalFragmentConsistency(clients []DBClient, client DBClient, table string, withRepair bool) (repositories.FragmentConsistency, error) {
+	key := fragmentationCatalog[table].key
+	columns := getFragmentColumns(table, client.name)
+	predicateTemplate := fragmentationCatalog[table].predicateTemplate
+
+	actual, err := queryFragmentRows(client, getFragmentSelectStatement(table, client.name), columns, key)
+	if err != nil {
+		return repositories.FragmentConsistency{}, err
+	}
+
+	expected, err := queryFragmentRows(client, fmt.Sprintf("%s WHERE %s",
+		getFragmentSelectStatement(table, client.name),
+		getSucursalaPredicate(predicateTemplate, client.name, client.tableSuffix),
+	), columns, key)
+	if err != nil {
+		return repositories.FragmentConsistency{}, err
+	}
+
+	placedElsewhere := make(map[string]bool)
+	for _, source := range clients {
+		if source.name == client.name {
+			continue
+		}
+
+		misplaced, err := queryFragmentRows(source, fmt.Sprintf("%s WHERE %s",
+			getFragmentSelectStatement(table, source.name),
+			getSucursalaPredicate(predicateTemplate, client.name, source.tableSuffix),
+		), columns, key)
+		if err != nil {
+			return repositories.FragmentConsistency{}, err
+		}
+		for _, keyValue := range misplaced.keys {
+			if _, ok := expected.rows[keyValue]; !ok {
+				expected.keys = append(expected.keys, keyValue)
+				expected.rows[keyValue] = misplaced.rows[keyValue]
+			}
+		}
+
+		placed, err := queryFragmentRows(source, fmt.Sprintf("%s WHERE %s",
+			getFragmentSelectStatement(table, source.name),
+			getSucursalaPredicate(predicateTemplate, source.name, source.tableSuffix),
+		), columns, key)
+		if err != nil {
+			return repositories.FragmentConsistency{}, err
+		}
+		for _, keyValue := range placed.keys {
+			placedElsewhere[keyValue] = true
+		}
+	}
+	sort.Strings(expected.keys)
+
+	return compareFragmentRows(client, table, expected, actual, withRepair, func(keyValue string) bool {
+		return placedElsewhere[keyValue]
+	}), nil
+}
+
+// compareFragmentRows reports how actual differs from expected. Rows in plus
+// are only given a DELETE repair when canDelete allows it.
+func compareFragmentRows(client DBClient, table string, expected fragmentRows, actual fragmentRows, withRepair bool, canDelete func(keyValue string) bool) repositories.FragmentConsistency {
+	key := fragmentationCatalog[table].key
+	columns := getFragmentColumns(table, client.name)
+
+	consistency := repositories.FragmentConsistency{
+		Tabela:        table,
+		Site:          client.name,
+		RanduriLipsa:  []map[string]interface{}{},
+		RanduriInPlus: []map[string]interface{}{},
+		Diferente:     []repositories.DiferentaColoana{},
+	}
+	fragmentTable := fmt.Sprintf(`"%s%s"`, table, client.tableSuffix)
+
+	for _, keyValue := range expected.keys {
+		expectedRow := expected.rows[keyValue]
+		actualRow, ok := actual.rows[keyValue]
+		if !ok {
+			consistency.RanduriLipsa = append(consistency.RanduriLipsa, expectedRow)
+			if withRepair {
+				consistency.Reparatii = append(consistency.Reparatii, getRepairInsert(fragmentTable, columns, expectedRow))
+			}
+			continue
+		}
+
+		for _, column := range columns {
+			if sqlValuesEqual(expectedRow[column], actualRow[column]) {
+				continue
+			}
+
+			consistency.Diferente = append(consistency.Diferente, repositories.DiferentaColoana{
+				Cheie:          getKeyColumns(expectedRow, key),
+				Coloana:        column,
+				ValoareGlobala: expectedRow[column],
+				ValoareLocala:  actualRow[column],
+			})
+			if withRepair {
+				consistency.Reparatii = append(consistency.Reparatii, fmt.Sprintf(
+					`UPDATE %s SET "%s" = %s WHERE %s`,
+					fragmentTable, column, getSQLLiteral(expectedRow[column]), getKeyCondition(expectedRow, key),
+				))
+			}
+		}
+	}
+
+	for _, keyValue := range actual.keys {
+		if _, ok := expected.rows[keyValue]; ok {
+			continue
+		}
+
+		actualRow := actual.rows[keyValue]
+		consistency.RanduriInPlus = append(consistency.RanduriInPlus, actualRow)
+		if withRepair && canDelete(keyValue) {
+			consistency.Reparatii = append(consistency.Reparatii, fmt.Sprintf(`DELETE FROM %s WHERE %s`, fragmentTable, getKeyCondition(actualRow, key)))
+		}
+	}
+
+	return consistency
+}
+
+func queryFragmentRows(client DBClient, query string, columns []string, key []string) (fragmentRows, error) {
+	result := fragmentRows{rows: make(map[string]map[string]interface{})}
+
+	rows, err := client.db.Query(query)
+	if err != nil {
+		return fragmentRows{}, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		destinations := make([]interface{}, len(columns))
+		for i := range values {
+			destinations[i] = &values[i]
+		}
+
+		err := rows.Scan(destinations...)
+		if err != nil {
+			return fragmentRows{}, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			row[column] = normalizeSQLValue(values[i])
+		}
+
+		keyValue := getRowKey(row, key)
+		if _, ok := result.rows[keyValue]; !ok {
+			result.keys = append(result.keys, keyValue)
+		}
+		result.rows[keyValue] = row
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return fragmentRows{}, err
+	}
+
+	sort.Strings(result.keys)
+
+	return result, nil
+}
+
+func getKeyColumns(row map[string]interface{}, key []string) map[string]interface{} {
+	keyColumns := make(map[string]interface{}, len(key))
+	for _, column := range key {
+		keyColumns[column] = row[column]
+	}
+
+	return keyColumns
+}
+
+func getRowKey(row map[string]interface{}, key []string) string {
+	values := make([]string, len(key))
+	for i, column := range key {
+		values[i] = fmt.Sprint(row[column])
+	}
+
+	return strings.Join(values, "|")
+}
+
+func getKeyCondition(row map[string]interface{}, key []string) string {
+	conditions := make([]string, len(key))
+	for i, column := range key {
+		conditions[i] = fmt.Sprintf(`"%s" = %s`, column, getSQLLiteral(row[column]))
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+func getRepairInsert(fragmentTable string, columns []string, row map[string]interface{}) string {
+	values := make([]string, len(columns))
+	for i, column := range columns {
+		values[i] = getSQLLiteral(row[column])
+	}
+
+	return fmt.Sprintf(`INSERT INTO %s(%s) VALUES(%s)`, fragmentTable, strings.Join(quoteColumns(columns), ", "), strings.Join(values, ", "))
+}
+
+func normalizeSQLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.UTC()
+	default:
+		return v
+	}
+}
+
+func sqlValuesEqual(a interface{}, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+func getSQLLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("'%s'", strings.Replace(v, "'", "''", -1))
+	case time.Time:
+		return fmt.Sprintf("TO_DATE('%s', 'YYYY-MM-DD HH24:MI:SS')", v.Format("2006-01-02 15:04:05"))
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -2,8 +2,9 @@ package datasources
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"strings"
+	"strconv"
 	"time"
 
 	_ "github.com/sijms/go-ora"
@@ -12,13 +13,34 @@ import (
 )
 
 type (
+	execer interface {
+		Query(query string, args ...interface{}) (*sql.Rows, error)
+		Exec(query string, args ...interface{}) (sql.Result, error)
+	}
+
+	transaction interface {
+		execer
+		Commit() error
+		Rollback() error
+	}
+
+	database interface {
+		execer
+		Prepare(query string) (*sql.Stmt, error)
+		Begin() (transaction, error)
+	}
+
+	oracleDB struct {
+		*sql.DB
+	}
+
 	DBClient struct {
-		db          *sql.DB
+		db          database
 		name        string
 		tableSuffix string
 	}
 
-	Connections map[string]DBClient
+	Connections map[string]Store
 )
 
 const (
@@ -29,6 +51,16 @@ const (
 	Local4ConnectionName = "local4"
 )
 
+const vanzariPageSize = 15
+
+var connectionNames = []string{
+	GlobalConnectionName,
+	Local1ConnectionName,
+	Local2ConnectionName,
+	Local3ConnectionName,
+	Local4ConnectionName,
+}
+
 func GetClient(user string, password string, hostname string, dbName string, connectionName string) DBClient {
 	db, err := sql.Open(
 		"oracle",
@@ -43,430 +75,154 @@ func GetClient(user string, password string, hostname string, dbName string, con
 	db.SetMaxIdleConns(100)
 
 	return DBClient{
-		db:          db,
+		db:          oracleDB{db},
 		name:        connectionName,
 		tableSuffix: getTableSuffix(connectionName),
 	}
 }
 
-func (client DBClient) GetParteneri() ([]repositories.Partener, error) {
-	var (
-		parteneri []repositories.Partener
-		cod       string
-		nume      string
-		cui       string
-		email     string
-		IDAdresa  int
-	)
-
-	switch client.name {
-	default:
-	case GlobalConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodPartener", "NumePartener", "CUI", "EMail", "IdAdresa" FROM "Parteneri%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Partener{}, err
-		}
-
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&cod, &nume, &cui, &email, &IDAdresa)
-			if err != nil {
-				return []repositories.Partener{}, err
-			}
-
-			parteneri = append(
-				parteneri,
-				repositories.Partener{
-					CodPartener:  cod,
-					NumePartener: nume,
-					CUI:          cui,
-					Email:        email,
-					IDAdresa:     IDAdresa,
-				},
-			)
-		}
-
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Partener{}, err
-		}
-		break
-
-	case Local1ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodPartener", "NumePartener", "IdAdresa" FROM "Parteneri%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Partener{}, err
-		}
+func (db oracleDB) Begin() (transaction, error) {
+	return db.DB.Begin()
+}
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&cod, &nume, &IDAdresa)
-			if err != nil {
-				return []repositories.Partener{}, err
-			}
+func (client DBClient) sqlClient() DBClient {
+	return client
+}
 
-			parteneri = append(
-				parteneri,
-				repositories.Partener{
-					CodPartener:  cod,
-					NumePartener: nume,
-					CUI:          cui,
-					Email:        email,
-					IDAdresa:     IDAdresa,
-				},
-			)
-		}
+func (client DBClient) GetParteneri() ([]repositories.Partener, error) {
+	var parteneri []repositories.Partener
 
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Partener{}, err
-		}
-		break
+	columns := getFragmentColumns("Parteneri", client.name)
+	rows, err := client.db.Query(getFragmentSelectStatement("Parteneri", client.name))
+	if err != nil {
+		return []repositories.Partener{}, err
+	}
 
-	case Local2ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodPartener", "CUI", "IdAdresa" FROM "Parteneri%s"`, client.tableSuffix),
-		)
+	defer rows.Close()
+	for rows.Next() {
+		var partener repositories.Partener
+		err := scanFragmentRow(rows, columns, getPartenerFields(&partener))
 		if err != nil {
 			return []repositories.Partener{}, err
 		}
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&cod, &cui, &IDAdresa)
-			if err != nil {
-				return []repositories.Partener{}, err
-			}
-
-			parteneri = append(
-				parteneri,
-				repositories.Partener{
-					CodPartener:  cod,
-					NumePartener: nume,
-					CUI:          cui,
-					Email:        email,
-					IDAdresa:     IDAdresa,
-				},
-			)
-		}
-
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Partener{}, err
-		}
-		break
+		parteneri = append(parteneri, partener)
+	}
 
-	case Local3ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodPartener", "EMail", "IdAdresa" FROM "Parteneri%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Partener{}, err
-		}
+	err = rows.Err()
+	if err != nil {
+		return []repositories.Partener{}, err
+	}
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&cod, &email, &IDAdresa)
-			if err != nil {
-				return []repositories.Partener{}, err
-			}
+	return parteneri, nil
+}
 
-			parteneri = append(
-				parteneri,
-				repositories.Partener{
-					CodPartener:  cod,
-					NumePartener: nume,
-					CUI:          cui,
-					Email:        email,
-					IDAdresa:     IDAdresa,
-				},
-			)
-		}
+func (client DBClient) GetPartener(codPartener string) (repositories.Partener, error) {
+	var partener repositories.Partener
 
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Partener{}, err
-		}
-		break
+	columns := getFragmentColumns("Parteneri", client.name)
+	rows, err := client.db.Query(
+		fmt.Sprintf(`%s WHERE "CodPartener" = :1`, getFragmentSelectStatement("Parteneri", client.name)),
+		codPartener,
+	)
+	if err != nil {
+		return repositories.Partener{}, err
+	}
 
-	case Local4ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodPartener", "IdAdresa" FROM "Parteneri%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Partener{}, err
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return repositories.Partener{}, err
 		}
+		return repositories.Partener{}, fmt.Errorf("partener %s: %w", codPartener, ErrNotFound)
+	}
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&cod, &IDAdresa)
-			if err != nil {
-				return []repositories.Partener{}, err
-			}
-
-			parteneri = append(
-				parteneri,
-				repositories.Partener{
-					CodPartener:  cod,
-					NumePartener: nume,
-					CUI:          cui,
-					Email:        email,
-					IDAdresa:     IDAdresa,
-				},
-			)
-		}
+	err = scanFragmentRow(rows, columns, getPartenerFields(&partener))
 
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Partener{}, err
-		}
-		break
+	return partener, err
+}
 
-	}
+func (client DBClient) CountVanzariPartener(codPartener string) (int, error) {
+	return client.countVanzariPartener(client.db, codPartener)
+}
 
-	return parteneri, nil
+func (client DBClient) countVanzariPartener(q execer, codPartener string) (int, error) {
+	return queryInt(q, fmt.Sprintf(`SELECT COUNT(*) FROM "Vanzari%s" WHERE "CodPartener" = :1`, client.tableSuffix), codPartener)
 }
 
-func (client DBClient) InsertPartener(partenerAdresa repositories.InsertPartener) error {
-	IDAdresa, err := client.InsertAdresa(partenerAdresa.Adresa)
+// lockPartener locks the partner row until the transaction of q ends, the way
+// getCantitateStoc locks an article.
+func (client DBClient) lockPartener(q execer, codPartener string) error {
+	result, err := q.Exec(fmt.Sprintf(`UPDATE "Parteneri%s" SET "CodPartener" = "CodPartener" WHERE "CodPartener" = :1`, client.tableSuffix), codPartener)
 	if err != nil {
 		return err
 	}
-
-	partener := partenerAdresa.Partener
-	stmt, err := client.db.Prepare(fmt.Sprintf(`INSERT INTO "Parteneri%s"("CodPartener", "NumePartener", "CUI", "EMail", "IdAdresa") VALUES(:1, :2, :3, :4, :5)`, client.tableSuffix))
+	updatedRows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if updatedRows == 0 {
+		return fmt.Errorf("partener %s: %w", codPartener, ErrNotFound)
+	}
 
-	_, err = stmt.Exec(
-		partener.CodPartener,
-		partener.NumePartener,
-		partener.CUI,
-		partener.Email,
-		IDAdresa,
-	)
-
-	return err
+	return nil
 }
 
-func (client DBClient) GetAdrese() ([]repositories.Adresa, error) {
-	var (
-		adrese     []repositories.Adresa
-		IDAdresa   int
-		numeAdresa string
-		oras       string
-		judet      string
-		sector     string
-		strada     string
-		numar      string
-		bloc       string
-		etaj       int
-	)
-
-	switch client.name {
-	default:
-	case GlobalConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "IdAdresa", "NumeAdresa", "Oras", "Judet", "Sector", "Strada", "Numar", "Bloc", "Etaj" FROM "Adrese%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
-
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&IDAdresa, &numeAdresa, &oras, &judet, &sector, &strada, &numar, &bloc, &etaj)
-			if err != nil {
-				return []repositories.Adresa{}, err
-			}
-
-			adrese = append(
-				adrese,
-				repositories.Adresa{
-					IDAdresa:   IDAdresa,
-					NumeAdresa: numeAdresa,
-					Oras:       oras,
-					Judet:      judet,
-					Sector:     sector,
-					Strada:     strada,
-					Numar:      numar,
-					Bloc:       bloc,
-					Etaj:       etaj,
-				},
-			)
-		}
-
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
-		break
-
-	case Local1ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "IdAdresa", "NumeAdresa" FROM "Adrese%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
-
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&IDAdresa, &numeAdresa)
-			if err != nil {
-				return []repositories.Adresa{}, err
-			}
-
-			adrese = append(
-				adrese,
-				repositories.Adresa{
-					IDAdresa:   IDAdresa,
-					NumeAdresa: numeAdresa,
-					Oras:       oras,
-					Judet:      judet,
-					Sector:     sector,
-					Strada:     strada,
-					Numar:      numar,
-					Bloc:       bloc,
-					Etaj:       etaj,
-				},
-			)
-		}
-
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
-		break
-
-	case Local2ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "IdAdresa", "Oras" "Adrese%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
-
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&IDAdresa, &oras)
-			if err != nil {
-				return []repositories.Adresa{}, err
-			}
-
-			adrese = append(
-				adrese,
-				repositories.Adresa{
-					IDAdresa:   IDAdresa,
-					NumeAdresa: numeAdresa,
-					Oras:       oras,
-					Judet:      judet,
-					Sector:     sector,
-					Strada:     strada,
-					Numar:      numar,
-					Bloc:       bloc,
-					Etaj:       etaj,
-				},
-			)
-		}
-
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
-		break
-
-	case Local3ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "IdAdresa", "Judet" FROM "Adrese%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
+func (client DBClient) getIDAdresaPartener(q execer, codPartener string) (int, error) {
+	IDAdresa, err := queryInt(q, fmt.Sprintf(`SELECT NVL("IdAdresa", 0) FROM "Parteneri%s" WHERE "CodPartener" = :1`, client.tableSuffix), codPartener)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("partener %s: %w", codPartener, ErrNotFound)
+	}
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&IDAdresa, &judet)
-			if err != nil {
-				return []repositories.Adresa{}, err
-			}
+	return IDAdresa, err
+}
 
-			adrese = append(
-				adrese,
-				repositories.Adresa{
-					IDAdresa:   IDAdresa,
-					NumeAdresa: numeAdresa,
-					Oras:       oras,
-					Judet:      judet,
-					Sector:     sector,
-					Strada:     strada,
-					Numar:      numar,
-					Bloc:       bloc,
-					Etaj:       etaj,
-				},
-			)
-		}
+func (client DBClient) GetAdrese() ([]repositories.Adresa, error) {
+	var adrese []repositories.Adresa
 
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
-		break
+	columns := getFragmentColumns("Adrese", client.name)
+	rows, err := client.db.Query(getFragmentSelectStatement("Adrese", client.name))
+	if err != nil {
+		return []repositories.Adresa{}, err
+	}
 
-	case Local4ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "IdAdresa", "Sector", "Strada", "Numar", "Bloc", "Etaj" FROM "Adrese%s"`, client.tableSuffix),
-		)
+	defer rows.Close()
+	for rows.Next() {
+		var adresa repositories.Adresa
+		err := scanFragmentRow(rows, columns, getAdresaFields(&adresa))
 		if err != nil {
 			return []repositories.Adresa{}, err
 		}
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&IDAdresa, &sector, &strada, &numar, &bloc, &etaj)
-			if err != nil {
-				return []repositories.Adresa{}, err
-			}
-
-			adrese = append(
-				adrese,
-				repositories.Adresa{
-					IDAdresa:   IDAdresa,
-					NumeAdresa: numeAdresa,
-					Oras:       oras,
-					Judet:      judet,
-					Sector:     sector,
-					Strada:     strada,
-					Numar:      numar,
-					Bloc:       bloc,
-					Etaj:       etaj,
-				},
-			)
-		}
-
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Adresa{}, err
-		}
-		break
+		adrese = append(adrese, adresa)
+	}
 
+	err = rows.Err()
+	if err != nil {
+		return []repositories.Adresa{}, err
 	}
 
 	return adrese, nil
 }
 
 func (client DBClient) InsertAdresa(adresa repositories.Adresa) (int, error) {
-	stmt, err := client.db.Prepare(fmt.Sprintf(`INSERT INTO "Adrese%s"("NumeAdresa", "Oras", "Judet", "Sector", "Strada", "Numar", "Bloc", "Etaj") VALUES(:1, :2, :3, :4, :5, :6, :7, :8)`, client.tableSuffix))
+	IDAdresa, err := nextID(adreseSequence)
+	if err != nil {
+		return -1, err
+	}
+
+	err = client.insertAdresa(client.db, IDAdresa, adresa)
 	if err != nil {
 		return -1, err
 	}
 
-	_, err = stmt.Exec(
+	return IDAdresa, nil
+}
+
+func (client DBClient) insertAdresa(q execer, IDAdresa int, adresa repositories.Adresa) error {
+	_, err := q.Exec(
+		fmt.Sprintf(`INSERT INTO "Adrese%s"("IdAdresa", "NumeAdresa", "Oras", "Judet", "Sector", "Strada", "Numar", "Bloc", "Etaj") VALUES(:1, :2, :3, :4, :5, :6, :7, :8, :9)`, client.tableSuffix),
+		IDAdresa,
 		adresa.NumeAdresa,
 		adresa.Oras,
 		adresa.Judet,
@@ -476,62 +232,107 @@ func (client DBClient) InsertAdresa(adresa repositories.Adresa) (int, error) {
 		adresa.Bloc,
 		adresa.Etaj,
 	)
-	if err != nil {
-		return -1, err
-	}
 
-	var IDAdresa int
-	rows, err := client.db.Query(fmt.Sprintf(`SELECT NVL(MAX("IdAdresa"), 0) FROM "Adrese%s"`, client.tableSuffix))
+	return err
+}
+
+func (client DBClient) editAdresa(q execer, IDAdresa int, adresa repositories.Adresa) error {
+	_, err := q.Exec(
+		fmt.Sprintf(`UPDATE "Adrese%s" SET "NumeAdresa" = :1, "Oras" = :2, "Judet" = :3, "Sector" = :4, "Strada" = :5, "Numar" = :6, "Bloc" = :7, "Etaj" = :8 WHERE "IdAdresa" = :9`, client.tableSuffix),
+		adresa.NumeAdresa,
+		adresa.Oras,
+		adresa.Judet,
+		adresa.Sector,
+		adresa.Strada,
+		adresa.Numar,
+		adresa.Bloc,
+		adresa.Etaj,
+		IDAdresa,
+	)
+
+	return err
+}
+
+// deleteUnusedAdresa reports whether the address was deleted, that is whether
+// nothing on this site used it.
+func (client DBClient) deleteUnusedAdresa(q execer, IDAdresa int) (bool, error) {
+	result, err := q.Exec(
+		fmt.Sprintf(`
+			DELETE FROM "Adrese%s" WHERE "IdAdresa" = :1
+			AND NOT EXISTS (SELECT 1 FROM "Parteneri%s" WHERE "IdAdresa" = :2)
+			AND NOT EXISTS (SELECT 1 FROM "Vanzatori%s" WHERE "IdAdresa" = :3)
+			AND NOT EXISTS (SELECT 1 FROM "Sucursale%s" WHERE "IdAdresa" = :4)
+		`, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix),
+		IDAdresa,
+		IDAdresa,
+		IDAdresa,
+		IDAdresa,
+	)
 	if err != nil {
-		return -1, err
+		return false, err
 	}
-	defer rows.Close()
-	rows.Next()
-	err = rows.Scan(&IDAdresa)
 
-	return IDAdresa, err
+	deleted, err := result.RowsAffected()
+
+	return deleted > 0, err
 }
 
-func (client DBClient) GetVanzari() ([]repositories.Vanzare, error) {
+// GetVanzari returns up to params.Limita + 1 sales after cursor, in the order
+// of params, so the caller can tell whether another page follows. A cursor
+// with Inapoi reads the sales before it, nearest first.
+func (client DBClient) GetVanzari(params repositories.VanzariParams, cursor *VanzariCursor) ([]VanzareSortata, error) {
 	var (
-		vanzari     []repositories.Vanzare
+		vanzari     []VanzareSortata
 		id          int
 		codPartener string
 		status      string
 		data        string
 		dataLivrare string
-		total       float32
-		vat         float32
-		discount    float32
+		total       repositories.Money
+		vat         repositories.Money
+		discount    repositories.Money
 		moneda      string
-		platit      float32
+		platit      repositories.Money
 		comentarii  string
 		codVanzator int
 		IDSucursala int
+		dataSortare string
 	)
 
+	descrescator := params.Descrescator
+	filter := getVanzariParamsFilter(params)
+	if cursor != nil {
+		descrescator = descrescator != cursor.Inapoi
+		cursorFilter, err := getVanzariCursorFilter(*cursor, descrescator)
+		if err != nil {
+			return []VanzareSortata{}, err
+		}
+		filter.and(cursorFilter)
+	}
+
 	rows, err := client.db.Query(
-		fmt.Sprintf(`
-			SELECT "IdIntrare", "CodPartener", "Status", "Data", "DataLivrare", "Total", "Vat", "Discount", "Moneda", "Platit", NVL("Comentarii", 'N/A'), "CodVanzator", "IdSucursala" 
+		bindPlaceholders(fmt.Sprintf(`
+			SELECT "IdIntrare", "CodPartener", "Status", "Data", "DataLivrare", "Total", "Vat", "Discount", "Moneda", "Platit", NVL("Comentarii", 'N/A'), "CodVanzator", "IdSucursala", TO_CHAR("Data", 'YYYY-MM-DD HH24:MI:SS') 
 			FROM "Vanzari%s" 
-			ORDER BY "IdIntrare" DESC
-			OFFSET 0 ROWS FETCH NEXT 15 ROWS ONLY
-		`, client.tableSuffix),
+			%s
+			ORDER BY %s
+			OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY
+		`, client.tableSuffix, filter.whereStatement(), getVanzariOrderBy(params.Sortare, descrescator), params.Limita+1)),
+		filter.args...,
 	)
 	if err != nil {
-		return []repositories.Vanzare{}, err
+		return []VanzareSortata{}, err
 	}
 
 	defer rows.Close()
 	for rows.Next() {
-		err := rows.Scan(&id, &codPartener, &status, &data, &dataLivrare, &total, &vat, &discount, &moneda, &platit, &comentarii, &codVanzator, &IDSucursala)
+		err := rows.Scan(&id, &codPartener, &status, &data, &dataLivrare, &total, &vat, &discount, &moneda, &platit, &comentarii, &codVanzator, &IDSucursala, &dataSortare)
 		if err != nil {
-			return []repositories.Vanzare{}, err
+			return []VanzareSortata{}, err
 		}
 
-		vanzari = append(
-			vanzari,
-			repositories.Vanzare{
+		vanzare := VanzareSortata{
+			Vanzare: repositories.Vanzare{
 				IDIntrare:   id,
 				CodPartener: codPartener,
 				Status:      status,
@@ -546,80 +347,426 @@ func (client DBClient) GetVanzari() ([]repositories.Vanzare, error) {
 				CodVanzator: codVanzator,
 				IDSucursala: IDSucursala,
 			},
-		)
+		}
+		switch params.Sortare {
+		case SortareData:
+			vanzare.Cheie = dataSortare
+		case SortareTotal:
+			vanzare.Cheie = total.String()
+		}
+		vanzari = append(vanzari, vanzare)
 	}
 
 	err = rows.Err()
 	if err != nil {
-		return []repositories.Vanzare{}, err
+		return []VanzareSortata{}, err
 	}
 
 	return vanzari, nil
 }
 
-func (client DBClient) InsertVanzare(vanzareLinii repositories.InsertVanzare, general DBClient) error {
-	var lastIDIntrare int
-	rows, err := general.db.Query(fmt.Sprintf(`SELECT NVL(MAX("IdIntrare"), 0) FROM "Vanzari%s"`, general.tableSuffix))
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-	rows.Next()
-	err = rows.Scan(&lastIDIntrare)
+func (client DBClient) CountVanzari(params repositories.VanzariParams) (int, error) {
+	filter := getVanzariParamsFilter(params)
 
-	vanzare := vanzareLinii.Vanzare
-	vanzare.IDIntrare = lastIDIntrare + 1
-	stmt, err := client.db.Prepare(fmt.Sprintf(`INSERT INTO "Vanzari%s"("IdIntrare", "CodPartener", "Status", "Data", "DataLivrare", "Total", "Vat", "Discount", "Moneda", "Platit", "Comentarii", "CodVanzator", "IdSucursala") VALUES(:1, :2, :3, TO_DATE(:4, 'MM/DD/YYYY'), TO_DATE(:5, 'MM/DD/YYYY'), :6, :7, :8, :9, :10, :11, :12, :13)`, client.tableSuffix))
-	if err != nil {
-		return err
-	}
+	return queryInt(client.db, bindPlaceholders(fmt.Sprintf(`SELECT COUNT(*) FROM "Vanzari%s" %s`, client.tableSuffix, filter.whereStatement())), filter.args...)
+}
 
-	_, err = stmt.Exec(
-		vanzare.IDIntrare,
-		vanzare.CodPartener,
-		vanzare.Status,
-		vanzare.Data,
-		vanzare.DataLivrare,
-		vanzare.Total,
-		vanzare.VAT,
-		vanzare.Discount,
-		vanzare.Moneda,
-		vanzare.Platit,
-		vanzare.Comentarii,
-		vanzare.CodVanzator,
-		vanzare.IDSucursala,
-	)
+// InsertVanzare saves the sale on this site and takes the stock of its lines
+// from the Articole replicas of connections, in one distributed transaction.
+// The partner is locked on global, so it cannot be deleted meanwhile.
+func (client DBClient) InsertVanzare(vanzareLinii repositories.InsertVanzare, connections Connections) error {
+	IDIntrare, err := nextID(vanzariSequence)
 	if err != nil {
 		return err
 	}
 
-	for _, linie := range vanzareLinii.LiniiVanzari {
-		linie.IDIntrare = vanzare.IDIntrare
-		err = client.InsertLinieVanzare(linie)
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		vanzare := vanzareLinii.Vanzare
+		global, q, err := tx.enlist(connections[GlobalConnectionName])
 		if err != nil {
 			return err
 		}
+		err = global.lockPartener(q, vanzare.CodPartener)
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("partener %s does not exist: %w", vanzare.CodPartener, ErrInvalidFields)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, q, err = tx.enlist(client)
+		if err != nil {
+			return err
+		}
+
+		vanzare.IDIntrare = IDIntrare
+		_, err = q.Exec(
+			fmt.Sprintf(`INSERT INTO "Vanzari%s"("IdIntrare", "CodPartener", "Status", "Data", "DataLivrare", "Total", "Vat", "Discount", "Moneda", "Platit", "Comentarii", "CodVanzator", "IdSucursala") VALUES(:1, :2, :3, TO_DATE(:4, 'MM/DD/YYYY'), TO_DATE(:5, 'MM/DD/YYYY'), :6, :7, :8, :9, :10, :11, :12, :13)`, client.tableSuffix),
+			vanzare.IDIntrare,
+			vanzare.CodPartener,
+			vanzare.Status,
+			vanzare.Data,
+			vanzare.DataLivrare,
+			vanzare.Total,
+			vanzare.VAT,
+			vanzare.Discount,
+			vanzare.Moneda,
+			vanzare.Platit,
+			vanzare.Comentarii,
+			vanzare.CodVanzator,
+			vanzare.IDSucursala,
+		)
+		if err != nil {
+			return err
+		}
+
+		for i, linie := range vanzareLinii.LiniiVanzari {
+			linie.IDIntrare = vanzare.IDIntrare
+			linie.NumarLinie = i + 1
+			err = client.checkProiectLinie(q, vanzare.IDIntrare, linie.IDProiect)
+			if err == nil {
+				err = client.insertLinieVanzare(q, linie)
+			}
+			if err == nil {
+				err = takeStocLinie(tx, connections, linie)
+			}
+			if err != nil {
+				return LinieVanzareError{Linie: i + 1, CodArticol: linie.CodArticol, Err: err}
+			}
+		}
+
+		_, err = client.insertTranzitieVanzare(q, repositories.TranzitieVanzare{
+			IDIntrare:   vanzare.IDIntrare,
+			StatusNou:   vanzare.Status,
+			Data:        time.Now().Format("2006-01-02 15:04:05"),
+			CodVanzator: vanzare.CodVanzator,
+		})
+		if err != nil {
+			return err
+		}
+
+		return client.updateVanzareTotals(q, vanzare.IDIntrare)
+	})
+}
+
+func (client DBClient) GetVanzare(IDIntrare int) (repositories.Vanzare, error) {
+	var vanzare repositories.Vanzare
+
+	rows, err := client.db.Query(
+		fmt.Sprintf(`
+			SELECT "IdIntrare", "CodPartener", "Status", "Data", "DataLivrare", "Total", "Vat", "Discount", "Moneda", "Platit", NVL("Comentarii", 'N/A'), "CodVanzator", "IdSucursala" 
+			FROM "Vanzari%s" 
+			WHERE "IdIntrare" = :1
+		`, client.tableSuffix),
+		IDIntrare,
+	)
+	if err != nil {
+		return repositories.Vanzare{}, err
+	}
+
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return repositories.Vanzare{}, err
+		}
+		return repositories.Vanzare{}, fmt.Errorf("vanzarea %d: %w", IDIntrare, ErrNotFound)
+	}
+
+	err = rows.Scan(
+		&vanzare.IDIntrare,
+		&vanzare.CodPartener,
+		&vanzare.Status,
+		&vanzare.Data,
+		&vanzare.DataLivrare,
+		&vanzare.Total,
+		&vanzare.VAT,
+		&vanzare.Discount,
+		&vanzare.Moneda,
+		&vanzare.Platit,
+		&vanzare.Comentarii,
+		&vanzare.CodVanzator,
+		&vanzare.IDSucursala,
+	)
+
+	return vanzare, err
+}
+
+// EditVanzare changes the header fields that are not computed from the lines:
+// DataLivrare, Platit and Comentarii. Status only changes through
+// TransitionVanzare, so a different Status is refused.
+func (client DBClient) EditVanzare(vanzare repositories.Vanzare) error {
+	return client.runTransaction(func(q execer) error {
+		err := client.lockVanzare(q, vanzare.IDIntrare)
+		if err != nil {
+			return err
+		}
+
+		status, _, err := client.getStatusVanzare(q, vanzare.IDIntrare)
+		if err != nil {
+			return err
+		}
+		if len(vanzare.Status) > 0 && vanzare.Status != status {
+			return fmt.Errorf("vanzarea %d is %s, its Status is changed through /vanzari/%d/transition: %w", vanzare.IDIntrare, status, vanzare.IDIntrare, ErrInvalidTransition)
+		}
+
+		// Total, VAT and Discount are the sums over the lines: the ones sent
+		// are checked by pricing the sale again, and the computed ones saved.
+		linii, err := client.queryLiniiVanzare(q, vanzare.IDIntrare)
+		if err != nil {
+			return err
+		}
+		vanzareLinii := repositories.InsertVanzare{Vanzare: vanzare, LiniiVanzari: linii}
+		err = PriceVanzare(&vanzareLinii)
+		if err != nil {
+			return err
+		}
+		vanzare = vanzareLinii.Vanzare
+
+		_, err = q.Exec(
+			fmt.Sprintf(`UPDATE "Vanzari%s" SET "DataLivrare" = TO_DATE(:1, 'MM/DD/YYYY'), "Platit" = :2, "Comentarii" = :3, "Total" = :4, "Vat" = :5, "Discount" = :6 WHERE "IdIntrare" = :7`, client.tableSuffix),
+			vanzare.DataLivrare,
+			vanzare.Platit,
+			vanzare.Comentarii,
+			vanzare.Total,
+			vanzare.VAT,
+			vanzare.Discount,
+			vanzare.IDIntrare,
+		)
+
+		return err
+	})
+}
+
+// TransitionVanzare moves the sale to tranzitie.StatusNou if its lifecycle
+// allows it and records the transition. CodVanzator defaults to the seller of
+// the sale. Cancelling a sale puts the stock of its lines back on the Articole
+// replicas of connections.
+func (client DBClient) TransitionVanzare(tranzitie repositories.TranzitieVanzare, connections Connections) (repositories.TranzitieVanzare, error) {
+	tranzitie.Data = time.Now().Format("2006-01-02 15:04:05")
+
+	err := runDistributedTransaction(func(tx *distributedTransaction) error {
+		_, q, err := tx.enlist(client)
+		if err != nil {
+			return err
+		}
+
+		err = client.lockVanzare(q, tranzitie.IDIntrare)
+		if err != nil {
+			return err
+		}
+
+		status, codVanzator, err := client.getStatusVanzare(q, tranzitie.IDIntrare)
+		if err != nil {
+			return err
+		}
+		if !canTransitionVanzare(status, tranzitie.StatusNou) {
+			return fmt.Errorf("vanzarea %d cannot go from %s to %s, allowed: %s: %w", tranzitie.IDIntrare, status, tranzitie.StatusNou, getAllowedTransitions(status), ErrInvalidTransition)
+		}
+		tranzitie.StatusVechi = status
+		if tranzitie.CodVanzator == 0 {
+			tranzitie.CodVanzator = codVanzator
+		}
+
+		_, err = q.Exec(fmt.Sprintf(`UPDATE "Vanzari%s" SET "Status" = :1 WHERE "IdIntrare" = :2`, client.tableSuffix), tranzitie.StatusNou, tranzitie.IDIntrare)
+		if err != nil {
+			return err
+		}
+
+		if tranzitie.StatusNou == VanzareAnulata {
+			linii, err := client.queryLiniiVanzare(q, tranzitie.IDIntrare)
+			if err != nil {
+				return err
+			}
+			for _, linie := range linii {
+				err = restoreStocLinie(tx, connections, linie)
+				if err != nil {
+					return LinieVanzareError{Linie: linie.NumarLinie, CodArticol: linie.CodArticol, Err: err}
+				}
+			}
+		}
+
+		tranzitie.NumarTranzitie, err = client.insertTranzitieVanzare(q, tranzitie)
+
+		return err
+	})
+	if err != nil {
+		return repositories.TranzitieVanzare{}, err
+	}
+
+	return tranzitie, nil
+}
+
+func (client DBClient) GetIstoricStatusVanzare(IDIntrare int) ([]repositories.TranzitieVanzare, error) {
+	var (
+		istoric     []repositories.TranzitieVanzare
+		tranzitie   repositories.TranzitieVanzare
+		statusVechi sql.NullString
+	)
+
+	rows, err := client.db.Query(
+		fmt.Sprintf(`SELECT "IdIntrare", "NumarTranzitie", "StatusVechi", "StatusNou", TO_CHAR("Data", 'YYYY-MM-DD HH24:MI:SS'), "CodVanzator" FROM "IstoricStatusVanzari%s" WHERE "IdIntrare" = :1 ORDER BY "NumarTranzitie"`, client.tableSuffix),
+		IDIntrare,
+	)
+	if err != nil {
+		return []repositories.TranzitieVanzare{}, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&tranzitie.IDIntrare, &tranzitie.NumarTranzitie, &statusVechi, &tranzitie.StatusNou, &tranzitie.Data, &tranzitie.CodVanzator)
+		if err != nil {
+			return []repositories.TranzitieVanzare{}, err
+		}
+		tranzitie.StatusVechi = statusVechi.String
+
+		istoric = append(istoric, tranzitie)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return []repositories.TranzitieVanzare{}, err
+	}
+
+	return istoric, nil
+}
+
+// insertTranzitieVanzare numbers the transition after the last one recorded
+// for the sale; the header must be locked.
+func (client DBClient) insertTranzitieVanzare(q execer, tranzitie repositories.TranzitieVanzare) (int, error) {
+	numarTranzitie, err := queryInt(
+		q,
+		fmt.Sprintf(`SELECT NVL(MAX("NumarTranzitie"), 0) + 1 FROM "IstoricStatusVanzari%s" WHERE "IdIntrare" = :1`, client.tableSuffix),
+		tranzitie.IDIntrare,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = q.Exec(
+		fmt.Sprintf(`INSERT INTO "IstoricStatusVanzari%s"("IdIntrare", "NumarTranzitie", "StatusVechi", "StatusNou", "Data", "CodVanzator") VALUES(:1, :2, :3, :4, TO_DATE(:5, 'YYYY-MM-DD HH24:MI:SS'), :6)`, client.tableSuffix),
+		tranzitie.IDIntrare,
+		numarTranzitie,
+		tranzitie.StatusVechi,
+		tranzitie.StatusNou,
+		tranzitie.Data,
+		tranzitie.CodVanzator,
+	)
+
+	return numarTranzitie, err
+}
+
+func (client DBClient) getStatusVanzare(q execer, IDIntrare int) (string, int, error) {
+	var (
+		status      string
+		codVanzator int
+	)
+
+	rows, err := q.Query(fmt.Sprintf(`SELECT "Status", "CodVanzator" FROM "Vanzari%s" WHERE "IdIntrare" = :1`, client.tableSuffix), IDIntrare)
+	if err != nil {
+		return "", 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return "", 0, err
+		}
+		return "", 0, fmt.Errorf("vanzarea %d: %w", IDIntrare, ErrNotFound)
+	}
+	err = rows.Scan(&status, &codVanzator)
+
+	return status, codVanzator, err
+}
+
+// lockVanzare locks the header of the sale until the end of the transaction,
+// so its lines and totals change together, and refuses cancelled sales.
+func (client DBClient) lockVanzare(q execer, IDIntrare int) error {
+	result, err := q.Exec(
+		fmt.Sprintf(`UPDATE "Vanzari%s" SET "IdIntrare" = "IdIntrare" WHERE "IdIntrare" = :1`, client.tableSuffix),
+		IDIntrare,
+	)
+	if err != nil {
+		return err
+	}
+	lockedRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if lockedRows == 0 {
+		return fmt.Errorf("vanzarea %d does not exist on %s: %w", IDIntrare, client.name, ErrNotFound)
+	}
+
+	anulate, err := queryInt(
+		q,
+		fmt.Sprintf(`SELECT COUNT(*) FROM "Vanzari%s" WHERE "IdIntrare" = :1 AND "Status" = :2`, client.tableSuffix),
+		IDIntrare,
+		VanzareAnulata,
+	)
+	if err != nil {
+		return err
+	}
+	if anulate > 0 {
+		return fmt.Errorf("vanzarea %d: %w", IDIntrare, ErrVanzareAnulata)
 	}
 
 	return nil
 }
 
+// checkLiniiEditable refuses changes to the lines of a delivered or paid
+// sale, whose totals are final.
+func (client DBClient) checkLiniiEditable(q execer, IDIntrare int) error {
+	status, _, err := client.getStatusVanzare(q, IDIntrare)
+	if err != nil {
+		return err
+	}
+	if status == VanzareLivrata || status == VanzarePlatita {
+		return fmt.Errorf("vanzarea %d is %s, its lines cannot change: %w", IDIntrare, status, ErrInvalidTransition)
+	}
+
+	return nil
+}
+
+// updateVanzareTotals sets the Total, VAT and Discount of the header to the
+// sums over its lines.
+func (client DBClient) updateVanzareTotals(q execer, IDIntrare int) error {
+	_, err := q.Exec(
+		fmt.Sprintf(`
+			UPDATE "Vanzari%s" SET
+				"Total" = (SELECT NVL(SUM("TotalLinie"), 0) FROM "LiniiVanzari%s" WHERE "IdIntrare" = :1),
+				"Vat" = (SELECT NVL(SUM("Vat"), 0) FROM "LiniiVanzari%s" WHERE "IdIntrare" = :2),
+				"Discount" = (SELECT NVL(SUM("Discount"), 0) FROM "LiniiVanzari%s" WHERE "IdIntrare" = :3)
+			WHERE "IdIntrare" = :4
+		`, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix),
+		IDIntrare,
+		IDIntrare,
+		IDIntrare,
+		IDIntrare,
+	)
+
+	return err
+}
+
 func (client DBClient) GetLiniiVanzare(IDIntrareVanzari int) ([]repositories.LinieVanzare, error) {
+	return client.queryLiniiVanzare(client.db, IDIntrareVanzari)
+}
+
+func (client DBClient) queryLiniiVanzare(q execer, IDIntrareVanzari int) ([]repositories.LinieVanzare, error) {
 	var (
 		liniiVanzare []repositories.LinieVanzare
 		IDIntrare    int
 		numarLinie   int
 		codArticol   string
 		cantitate    float32
-		pret         float32
-		discount     float32
-		VAT          float32
-		totalLinie   float32
+		pret         repositories.Money
+		discount     repositories.Money
+		VAT          repositories.Money
+		totalLinie   repositories.Money
 		IDProiect    string
+		stocScazut   string
 	)
 
-	rows, err := client.db.Query(
-		fmt.Sprintf(`SELECT "IdIntrare", "NumarLinie", "CodArticol", "Cantitate", "Pret", "Discount", "Vat", "TotalLinie", "IdProiect" FROM "LiniiVanzari%s" WHERE "IdIntrare" = :1`, client.tableSuffix),
+	rows, err := q.Query(
+		fmt.Sprintf(`SELECT "IdIntrare", "NumarLinie", "CodArticol", "Cantitate", "Pret", "Discount", "Vat", "TotalLinie", "IdProiect", NVL("StocScazut", 'N') FROM "LiniiVanzari%s" WHERE "IdIntrare" = :1 ORDER BY "NumarLinie"`, client.tableSuffix),
 		IDIntrareVanzari,
 	)
 	if err != nil {
@@ -628,7 +775,7 @@ func (client DBClient) GetLiniiVanzare(IDIntrareVanzari int) ([]repositories.Lin
 
 	defer rows.Close()
 	for rows.Next() {
-		err := rows.Scan(&IDIntrare, &numarLinie, &codArticol, &cantitate, &pret, &discount, &VAT, &totalLinie, &IDProiect)
+		err := rows.Scan(&IDIntrare, &numarLinie, &codArticol, &cantitate, &pret, &discount, &VAT, &totalLinie, &IDProiect, &stocScazut)
 		if err != nil {
 			return []repositories.LinieVanzare{}, err
 		}
@@ -645,6 +792,7 @@ func (client DBClient) GetLiniiVanzare(IDIntrareVanzari int) ([]repositories.Lin
 				VAT:        VAT,
 				TotalLinie: totalLinie,
 				IDProiect:  IDProiect,
+				StocScazut: stocScazut == "Y",
 			},
 		)
 	}
@@ -657,27 +805,60 @@ func (client DBClient) GetLiniiVanzare(IDIntrareVanzari int) ([]repositories.Lin
 	return liniiVanzare, nil
 }
 
-func (client DBClient) InsertLinieVanzare(linie repositories.LinieVanzare) error {
-	var nrLinieVanzare int
-	rows, err := client.db.Query(
-		fmt.Sprintf(`SELECT NVL(MAX("NumarLinie"), 0) FROM "LiniiVanzari%s" WHERE "IdIntrare" = :1`, client.tableSuffix),
-		linie.IDIntrare,
-	)
-	if err != nil {
-		return err
-	}
-	defer rows.Close()
-	rows.Next()
-	err = rows.Scan(&nrLinieVanzare)
+// InsertLinieVanzare appends a line to an existing sale and takes its stock
+// from the Articole replicas of connections. The sale row is locked first so
+// concurrent appends to the same sale get distinct line numbers.
+func (client DBClient) InsertLinieVanzare(linie repositories.LinieVanzare, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		_, q, err := tx.enlist(client)
+		if err != nil {
+			return err
+		}
 
-	stmt, err := client.db.Prepare(fmt.Sprintf(`INSERT INTO "LiniiVanzari%s"("IdIntrare", "NumarLinie", "CodArticol", "Cantitate", "Pret", "Discount", "Vat", "TotalLinie", "IdProiect") VALUES(:1, :2, :3, :4, :5, :6, :7, :8, :9)`, client.tableSuffix))
-	if err != nil {
-		return err
-	}
+		err = client.lockVanzare(q, linie.IDIntrare)
+		if err != nil {
+			return err
+		}
+		err = client.checkLiniiEditable(q, linie.IDIntrare)
+		if err != nil {
+			return err
+		}
+
+		nrLinieVanzare, err := queryInt(
+			q,
+			fmt.Sprintf(`SELECT NVL(MAX("NumarLinie"), 0) FROM "LiniiVanzari%s" WHERE "IdIntrare" = :1`, client.tableSuffix),
+			linie.IDIntrare,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = client.checkProiectLinie(q, linie.IDIntrare, linie.IDProiect)
+		if err != nil {
+			return err
+		}
 
-	_, err = stmt.Exec(
+		linie.NumarLinie = nrLinieVanzare + 1
+		err = client.insertLinieVanzare(q, linie)
+		if err != nil {
+			return err
+		}
+		err = takeStocLinie(tx, connections, linie)
+		if err != nil {
+			return err
+		}
+
+		return client.updateVanzareTotals(q, linie.IDIntrare)
+	})
+}
+
+// insertLinieVanzare marks the line as having taken its stock, which the
+// callers do in the same transaction.
+func (client DBClient) insertLinieVanzare(q execer, linie repositories.LinieVanzare) error {
+	_, err := q.Exec(
+		fmt.Sprintf(`INSERT INTO "LiniiVanzari%s"("IdIntrare", "NumarLinie", "CodArticol", "Cantitate", "Pret", "Discount", "Vat", "TotalLinie", "IdProiect", "StocScazut") VALUES(:1, :2, :3, :4, :5, :6, :7, :8, :9, 'Y')`, client.tableSuffix),
 		linie.IDIntrare,
-		nrLinieVanzare+1,
+		linie.NumarLinie,
 		linie.CodArticol,
 		linie.Cantitate,
 		linie.Pret,
@@ -690,39 +871,131 @@ func (client DBClient) InsertLinieVanzare(linie repositories.LinieVanzare) error
 	return err
 }
 
-func (client DBClient) EditLinieVanzare(linie repositories.LinieVanzare) error {
-	stmt, err := client.db.Prepare(fmt.Sprintf(`UPDATE "LiniiVanzari%s" SET "CodArticol" = :1, "Cantitate" = :2, "Pret" = :3, "Discount" = :4, "Vat" = :5, "TotalLinie" = :6, "IdProiect" = :7 WHERE "IdIntrare" = :8 AND "NumarLinie" = :9`, client.tableSuffix))
+// EditLinieVanzare puts the stock of the saved line back before taking the
+// stock of the new one, so changing only the quantity needs only the
+// difference in stock. A line saved without taking stock takes it now.
+func (client DBClient) EditLinieVanzare(linie repositories.LinieVanzare, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		_, q, err := tx.enlist(client)
+		if err != nil {
+			return err
+		}
+
+		err = client.lockVanzare(q, linie.IDIntrare)
+		if err != nil {
+			return err
+		}
+		err = client.checkLiniiEditable(q, linie.IDIntrare)
+		if err != nil {
+			return err
+		}
+
+		linieSalvata, err := client.getLinieVanzare(q, linie.IDIntrare, linie.NumarLinie)
+		if err != nil {
+			return err
+		}
+		err = client.checkProiectLinie(q, linie.IDIntrare, linie.IDProiect)
+		if err != nil {
+			return err
+		}
+		err = restoreStocLinie(tx, connections, linieSalvata)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.Exec(
+			fmt.Sprintf(`UPDATE "LiniiVanzari%s" SET "CodArticol" = :1, "Cantitate" = :2, "Pret" = :3, "Discount" = :4, "Vat" = :5, "TotalLinie" = :6, "IdProiect" = :7, "StocScazut" = 'Y' WHERE "IdIntrare" = :8 AND "NumarLinie" = :9`, client.tableSuffix),
+			linie.CodArticol,
+			linie.Cantitate,
+			linie.Pret,
+			linie.Discount,
+			linie.VAT,
+			linie.TotalLinie,
+			linie.IDProiect,
+			linie.IDIntrare,
+			linie.NumarLinie,
+		)
+		if err != nil {
+			return err
+		}
+		err = takeStocLinie(tx, connections, linie)
+		if err != nil {
+			return err
+		}
+
+		return client.updateVanzareTotals(q, linie.IDIntrare)
+	})
+}
+
+// DeleteLinieVanzare deletes the line and puts its stock back on the Articole
+// replicas of connections.
+func (client DBClient) DeleteLinieVanzare(IDIntrare int, numarLinie int, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		_, q, err := tx.enlist(client)
+		if err != nil {
+			return err
+		}
+
+		err = client.lockVanzare(q, IDIntrare)
+		if err != nil {
+			return err
+		}
+		err = client.checkLiniiEditable(q, IDIntrare)
+		if err != nil {
+			return err
+		}
+
+		linie, err := client.getLinieVanzare(q, IDIntrare, numarLinie)
+		if err != nil {
+			return err
+		}
+
+		result, err := q.Exec(
+			fmt.Sprintf(`DELETE FROM "LiniiVanzari%s" WHERE "IdIntrare" = :1 AND "NumarLinie" = :2`, client.tableSuffix),
+			IDIntrare,
+			numarLinie,
+		)
+		if err != nil {
+			return err
+		}
+		err = checkLinieVanzareUpdated(result, IDIntrare, numarLinie)
+		if err != nil {
+			return err
+		}
+		err = restoreStocLinie(tx, connections, linie)
+		if err != nil {
+			return err
+		}
+
+		return client.updateVanzareTotals(q, IDIntrare)
+	})
+}
+
+func (client DBClient) getLinieVanzare(q execer, IDIntrare int, numarLinie int) (repositories.LinieVanzare, error) {
+	linii, err := client.queryLiniiVanzare(q, IDIntrare)
 	if err != nil {
-		return err
+		return repositories.LinieVanzare{}, err
 	}
 
-	_, err = stmt.Exec(
-		linie.CodArticol,
-		linie.Cantitate,
-		linie.Pret,
-		linie.Discount,
-		linie.VAT,
-		linie.TotalLinie,
-		linie.IDProiect,
-		linie.IDIntrare,
-		linie.NumarLinie,
-	)
+	for _, linie := range linii {
+		if linie.NumarLinie == numarLinie {
+			return linie, nil
+		}
+	}
 
-	return err
+	return repositories.LinieVanzare{}, fmt.Errorf("linia %d of vanzarea %d: %w", numarLinie, IDIntrare, ErrNotFound)
 }
 
-func (client DBClient) DeleteLinieVanzare(IDIntrare int, numarLinie int) error {
-	stmt, err := client.db.Prepare(fmt.Sprintf(`DELETE FROM "LiniiVanzari%s" WHERE "IdIntrare" = :1 AND "NumarLinie" = :2`, client.tableSuffix))
+func checkLinieVanzareUpdated(result sql.Result, IDIntrare int, numarLinie int) error {
+	updatedRows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if updatedRows == 0 {
+		return fmt.Errorf("linia %d of vanzarea %d: %w", numarLinie, IDIntrare, ErrNotFound)
+	}
 
-	_, err = stmt.Exec(
-		IDIntrare,
-		numarLinie,
-	)
-
-	return err
+	return nil
 }
 
 func (client DBClient) GetArticole() ([]repositories.Articol, error) {
@@ -763,245 +1036,369 @@ func (client DBClient) GetArticole() ([]repositories.Articol, error) {
 
 	err = rows.Err()
 	if err != nil {
-		return []repositories.Articol{}, err
+		return []repositories.Articol{}, err
+	}
+
+	return articole, nil
+}
+
+func (client DBClient) insertArticol(q execer, articol repositories.Articol) error {
+	_, err := q.Exec(
+		fmt.Sprintf(`INSERT INTO "Articole%s"("CodArticol", "NumeArticol", "CodGrupa", "CantitateStoc", "IdUnitateDeMasura") VALUES(:1, :2, :3, :4, :5)`, client.tableSuffix),
+		articol.CodArticol,
+		articol.NumeArticol,
+		articol.CodGrupa,
+		articol.CantitateStoc,
+		articol.IDUnitateMasura,
+	)
+
+	return err
+}
+
+// editArticol leaves CantitateStoc alone, stock only changes through a
+// MiscareStoc.
+func (client DBClient) editArticol(q execer, articol repositories.Articol) error {
+	result, err := q.Exec(
+		fmt.Sprintf(`UPDATE "Articole%s" SET "NumeArticol" = :1, "CodGrupa" = :2, "IdUnitateDeMasura" = :3 WHERE "CodArticol" = :4`, client.tableSuffix),
+		articol.NumeArticol,
+		articol.CodGrupa,
+		articol.IDUnitateMasura,
+		articol.CodArticol,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkArticolUpdated(result, articol.CodArticol)
+}
+
+func (client DBClient) deleteArticol(q execer, codArticol string) error {
+	result, err := q.Exec(fmt.Sprintf(`DELETE FROM "Articole%s" WHERE "CodArticol" = :1`, client.tableSuffix), codArticol)
+	if err != nil {
+		return err
+	}
+
+	return checkArticolUpdated(result, codArticol)
+}
+
+func (client DBClient) CountLiniiVanzariArticol(codArticol string) (int, error) {
+	return queryInt(client.db, fmt.Sprintf(`SELECT COUNT(*) FROM "LiniiVanzari%s" WHERE "CodArticol" = :1`, client.tableSuffix), codArticol)
+}
+
+// getCantitateStoc locks the article until the end of the transaction before
+// reading its stock.
+func (client DBClient) getCantitateStoc(q execer, codArticol string) (int, error) {
+	result, err := q.Exec(fmt.Sprintf(`UPDATE "Articole%s" SET "CantitateStoc" = "CantitateStoc" WHERE "CodArticol" = :1`, client.tableSuffix), codArticol)
+	if err != nil {
+		return 0, err
+	}
+	err = checkArticolUpdated(result, codArticol)
+	if err != nil {
+		return 0, err
+	}
+
+	return queryInt(q, fmt.Sprintf(`SELECT NVL("CantitateStoc", 0) FROM "Articole%s" WHERE "CodArticol" = :1`, client.tableSuffix), codArticol)
+}
+
+// addCantitateStoc changes the stock by diferenta rather than setting it, so a
+// statement redone on recovery, which the transaction marker guards to run
+// once, cannot overwrite stock changed by a later transaction.
+func (client DBClient) addCantitateStoc(q execer, codArticol string, diferenta int) error {
+	result, err := q.Exec(fmt.Sprintf(`UPDATE "Articole%s" SET "CantitateStoc" = NVL("CantitateStoc", 0) + :1 WHERE "CodArticol" = :2`, client.tableSuffix), diferenta, codArticol)
+	if err != nil {
+		return err
+	}
+
+	return checkArticolUpdated(result, codArticol)
+}
+
+func checkArticolUpdated(result sql.Result, codArticol string) error {
+	updatedRows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updatedRows == 0 {
+		return fmt.Errorf("articol %s: %w", codArticol, ErrNotFound)
+	}
+
+	return nil
+}
+
+func (client DBClient) GetMiscariStoc(codArticol string) ([]repositories.MiscareStoc, error) {
+	var (
+		miscari    []repositories.MiscareStoc
+		miscare    repositories.MiscareStoc
+		filter     sqlFilter
+		comentarii sql.NullString
+	)
+
+	if len(codArticol) > 0 {
+		filter.where(`"CodArticol" = ?`, codArticol)
+	}
+
+	rows, err := client.db.Query(
+		bindPlaceholders(fmt.Sprintf(
+			`SELECT "IdMiscare", "CodArticol", "Motiv", "Cantitate", "Diferenta", "CantitateStoc", TO_CHAR("Data", 'YYYY-MM-DD HH24:MI:SS'), "Comentarii" FROM "MiscariStoc%s" %s ORDER BY "IdMiscare"`,
+			client.tableSuffix, filter.whereStatement(),
+		)),
+		filter.args...,
+	)
+	if err != nil {
+		return []repositories.MiscareStoc{}, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&miscare.IDMiscare, &miscare.CodArticol, &miscare.Motiv, &miscare.Cantitate, &miscare.Diferenta, &miscare.CantitateStoc, &miscare.Data, &comentarii)
+		if err != nil {
+			return []repositories.MiscareStoc{}, err
+		}
+		miscare.Comentarii = comentarii.String
+
+		miscari = append(miscari, miscare)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return []repositories.MiscareStoc{}, err
+	}
+
+	return miscari, nil
+}
+
+func (client DBClient) insertMiscareStoc(q execer, miscare repositories.MiscareStoc) error {
+	_, err := q.Exec(
+		fmt.Sprintf(`INSERT INTO "MiscariStoc%s"("IdMiscare", "CodArticol", "Motiv", "Cantitate", "Diferenta", "CantitateStoc", "Data", "Comentarii") VALUES(:1, :2, :3, :4, :5, :6, TO_DATE(:7, 'YYYY-MM-DD HH24:MI:SS'), :8)`, client.tableSuffix),
+		miscare.IDMiscare,
+		miscare.CodArticol,
+		miscare.Motiv,
+		miscare.Cantitate,
+		miscare.Diferenta,
+		miscare.CantitateStoc,
+		miscare.Data,
+		miscare.Comentarii,
+	)
+
+	return err
+}
+
+func (client DBClient) GetVanzatori() ([]repositories.Vanzator, error) {
+	var vanzatori []repositories.Vanzator
+
+	columns := getFragmentColumns("Vanzatori", client.name)
+	rows, err := client.db.Query(getFragmentSelectStatement("Vanzatori", client.name))
+	if err != nil {
+		return []repositories.Vanzator{}, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var vanzator repositories.Vanzator
+		err := scanFragmentRow(rows, columns, getVanzatorFields(&vanzator))
+		if err != nil {
+			return []repositories.Vanzator{}, err
+		}
+
+		vanzatori = append(vanzatori, vanzator)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return []repositories.Vanzator{}, err
+	}
+
+	return vanzatori, nil
+}
+
+func (client DBClient) GetVanzator(codVanzator int) (repositories.Vanzator, error) {
+	var vanzator repositories.Vanzator
+
+	columns := getFragmentColumns("Vanzatori", client.name)
+	rows, err := client.db.Query(
+		fmt.Sprintf(`%s WHERE "CodVanzator" = :1`, getFragmentSelectStatement("Vanzatori", client.name)),
+		codVanzator,
+	)
+	if err != nil {
+		return repositories.Vanzator{}, err
+	}
+
+	defer rows.Close()
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return repositories.Vanzator{}, err
+		}
+		return repositories.Vanzator{}, fmt.Errorf("vanzator %d: %w", codVanzator, ErrNotFound)
+	}
+
+	err = scanFragmentRow(rows, columns, getVanzatorFields(&vanzator))
+
+	return vanzator, err
+}
+
+func (client DBClient) getIDAdresaVanzator(q execer, codVanzator int) (int, error) {
+	IDAdresa, err := queryInt(q, fmt.Sprintf(`SELECT NVL("IdAdresa", 0) FROM "Vanzatori%s" WHERE "CodVanzator" = :1`, client.tableSuffix), codVanzator)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("vanzator %d: %w", codVanzator, ErrNotFound)
+	}
+
+	return IDAdresa, err
+}
+
+func (client DBClient) GetIstoricVanzator(codVanzator int) ([]repositories.IstoricVanzator, error) {
+	var istoric []repositories.IstoricVanzator
+
+	rows, err := client.db.Query(
+		fmt.Sprintf(`SELECT "CodVanzator", TO_CHAR("DataInceput", 'MM/DD/YYYY'), "SalariuBaza", "Comision" FROM "IstoricVanzatori%s" WHERE "CodVanzator" = :1 ORDER BY "DataInceput"`, client.tableSuffix),
+		codVanzator,
+	)
+	if err != nil {
+		return []repositories.IstoricVanzator{}, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var perioada repositories.IstoricVanzator
+		err := rows.Scan(&perioada.CodVanzator, &perioada.DataInceput, &perioada.SalariuBaza, &perioada.Comision)
+		if err != nil {
+			return []repositories.IstoricVanzator{}, err
+		}
+
+		istoric = append(istoric, perioada)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return []repositories.IstoricVanzator{}, err
 	}
 
-	return articole, nil
+	return istoric, nil
 }
 
-func (client DBClient) InsertArticol(articol repositories.Articol) error {
-	stmt, err := client.db.Prepare(fmt.Sprintf(`INSERT INTO "Articole%s"("CodArticol", "NumeArticol", "CodGrupa", "CantitateStoc", "IdUnitateDeMasura") VALUES(:1, :2, :3, :4, :5)`, client.tableSuffix))
-	if err != nil {
-		return err
-	}
-
-	_, err = stmt.Exec(
-		articol.CodArticol,
-		articol.NumeArticol,
-		articol.CodGrupa,
-		articol.CantitateStoc,
-		articol.IDUnitateMasura,
+func (client DBClient) insertIstoricVanzator(q execer, vanzator repositories.Vanzator, dataInceput string) error {
+	_, err := q.Exec(
+		fmt.Sprintf(`INSERT INTO "IstoricVanzatori%s"("CodVanzator", "DataInceput", "SalariuBaza", "Comision") VALUES(:1, TO_DATE(:2, 'MM/DD/YYYY'), :3, :4)`, client.tableSuffix),
+		vanzator.CodVanzator,
+		dataInceput,
+		vanzator.SalariuBaza,
+		vanzator.Comision,
 	)
 
 	return err
 }
 
-func (client DBClient) GetVanzatori() ([]repositories.Vanzator, error) {
-	var (
-		vanzatori   []repositories.Vanzator
-		codVanzator int
-		nume        string
-		prenume     string
-		salariuBaza float32
-		comision    float32
-		email       string
-		IDAdresa    int
-	)
-
-	switch client.name {
-	default:
-	case GlobalConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodVanzator", "Nume", "Prenume", "SalariuBaza", "Comision", "EMail", "IdAdresa" FROM "Vanzatori%s"`, client.tableSuffix),
+// editIstoricVanzator records the SalariuBaza and Comision of vanzator from
+// dataInceput on, when they differ from the ones in effect at that date, and
+// returns the ones in effect today.
+func (client DBClient) editIstoricVanzator(q execer, vanzator repositories.Vanzator, dataInceput string) (repositories.IstoricVanzator, bool, error) {
+	perioade, err := queryInt(q, fmt.Sprintf(`SELECT COUNT(*) FROM "IstoricVanzatori%s" WHERE "CodVanzator" = :1`, client.tableSuffix), vanzator.CodVanzator)
+	if err != nil {
+		return repositories.IstoricVanzator{}, false, err
+	}
+	if perioade == 0 {
+		// Salespeople added before the history was kept start with the values they have now.
+		_, err = q.Exec(
+			fmt.Sprintf(`INSERT INTO "IstoricVanzatori%s"("CodVanzator", "DataInceput", "SalariuBaza", "Comision") SELECT "CodVanzator", TO_DATE(:1, 'MM/DD/YYYY'), "SalariuBaza", "Comision" FROM "Vanzatori%s" WHERE "CodVanzator" = :2`, client.tableSuffix, client.tableSuffix),
+			istoricStartDate,
+			vanzator.CodVanzator,
 		)
 		if err != nil {
-			return []repositories.Vanzator{}, err
-		}
-
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&codVanzator, &nume, &prenume, &salariuBaza, &comision, &email, &IDAdresa)
-			if err != nil {
-				return []repositories.Vanzator{}, err
-			}
-
-			vanzatori = append(
-				vanzatori,
-				repositories.Vanzator{
-					CodVanzator: codVanzator,
-					Nume:        nume,
-					Prenume:     prenume,
-					SalariuBaza: salariuBaza,
-					Comision:    comision,
-					Email:       email,
-					IDAdresa:    IDAdresa,
-				},
-			)
-		}
-
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Vanzator{}, err
+			return repositories.IstoricVanzator{}, false, err
 		}
-		break
+	}
 
-	case Local1ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodVanzator", "Nume", "Prenume", "IdAdresa" FROM "Vanzatori%s"`, client.tableSuffix),
+	current, found, err := client.getIstoricVanzatorAt(q, vanzator.CodVanzator, dataInceput)
+	if err != nil {
+		return repositories.IstoricVanzator{}, false, err
+	}
+	if !found || current.SalariuBaza != vanzator.SalariuBaza || current.Comision != vanzator.Comision {
+		result, err := q.Exec(
+			fmt.Sprintf(`UPDATE "IstoricVanzatori%s" SET "SalariuBaza" = :1, "Comision" = :2 WHERE "CodVanzator" = :3 AND "DataInceput" = TO_DATE(:4, 'MM/DD/YYYY')`, client.tableSuffix),
+			vanzator.SalariuBaza,
+			vanzator.Comision,
+			vanzator.CodVanzator,
+			dataInceput,
 		)
 		if err != nil {
-			return []repositories.Vanzator{}, err
-		}
-
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&codVanzator, &nume, &prenume, &IDAdresa)
-			if err != nil {
-				return []repositories.Vanzator{}, err
-			}
-
-			vanzatori = append(
-				vanzatori,
-				repositories.Vanzator{
-					CodVanzator: codVanzator,
-					Nume:        nume,
-					Prenume:     prenume,
-					SalariuBaza: salariuBaza,
-					Comision:    comision,
-					Email:       email,
-					IDAdresa:    IDAdresa,
-				},
-			)
-		}
-
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Vanzator{}, err
+			return repositories.IstoricVanzator{}, false, err
 		}
-		break
-
-	case Local2ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodVanzator", "SalariuBaza", "IdAdresa" FROM "Vanzatori%s"`, client.tableSuffix),
-		)
+		updatedRows, err := result.RowsAffected()
 		if err != nil {
-			return []repositories.Vanzator{}, err
+			return repositories.IstoricVanzator{}, false, err
 		}
-
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&codVanzator, &salariuBaza, &IDAdresa)
+		if updatedRows == 0 {
+			err = client.insertIstoricVanzator(q, vanzator, dataInceput)
 			if err != nil {
-				return []repositories.Vanzator{}, err
+				return repositories.IstoricVanzator{}, false, err
 			}
-
-			vanzatori = append(
-				vanzatori,
-				repositories.Vanzator{
-					CodVanzator: codVanzator,
-					Nume:        nume,
-					Prenume:     prenume,
-					SalariuBaza: salariuBaza,
-					Comision:    comision,
-					Email:       email,
-					IDAdresa:    IDAdresa,
-				},
-			)
 		}
+	}
 
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Vanzator{}, err
-		}
-		break
+	return client.getIstoricVanzatorAt(q, vanzator.CodVanzator, time.Now().Format("01/02/2006"))
+}
 
-	case Local3ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodVanzator", "Comision", "IdAdresa" FROM "Vanzatori%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Vanzator{}, err
-		}
+func (client DBClient) getIstoricVanzatorAt(q execer, codVanzator int, data string) (repositories.IstoricVanzator, bool, error) {
+	perioada := repositories.IstoricVanzator{CodVanzator: codVanzator}
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&codVanzator, &comision, &IDAdresa)
-			if err != nil {
-				return []repositories.Vanzator{}, err
-			}
+	rows, err := q.Query(
+		fmt.Sprintf(`
+			SELECT TO_CHAR("DataInceput", 'MM/DD/YYYY'), "SalariuBaza", "Comision" FROM "IstoricVanzatori%s"
+			WHERE "CodVanzator" = :1 AND "DataInceput" <= TO_DATE(:2, 'MM/DD/YYYY')
+			ORDER BY "DataInceput" DESC
+			OFFSET 0 ROWS FETCH NEXT 1 ROWS ONLY
+		`, client.tableSuffix),
+		codVanzator,
+		data,
+	)
+	if err != nil {
+		return perioada, false, err
+	}
 
-			vanzatori = append(
-				vanzatori,
-				repositories.Vanzator{
-					CodVanzator: codVanzator,
-					Nume:        nume,
-					Prenume:     prenume,
-					SalariuBaza: salariuBaza,
-					Comision:    comision,
-					Email:       email,
-					IDAdresa:    IDAdresa,
-				},
-			)
-		}
+	defer rows.Close()
+	if !rows.Next() {
+		return perioada, false, rows.Err()
+	}
+	err = rows.Scan(&perioada.DataInceput, &perioada.SalariuBaza, &perioada.Comision)
 
-		err = rows.Err()
-		if err != nil {
-			return []repositories.Vanzator{}, err
-		}
-		break
+	return perioada, err == nil, err
+}
 
-	case Local4ConnectionName:
-		rows, err := client.db.Query(
-			fmt.Sprintf(`SELECT "CodVanzator", "EMail", FROM "Vanzatori%s"`, client.tableSuffix),
-		)
-		if err != nil {
-			return []repositories.Vanzator{}, err
-		}
+func (client DBClient) GetVanzariVanzator(codVanzator int, dataStart string, dataEnd string) ([]repositories.Vanzare, error) {
+	var (
+		vanzari []repositories.Vanzare
+		filter  sqlFilter
+	)
 
-		defer rows.Close()
-		for rows.Next() {
-			err := rows.Scan(&codVanzator, &nume, &prenume, &salariuBaza, &comision, &email, &IDAdresa)
-			if err != nil {
-				return []repositories.Vanzator{}, err
-			}
+	filter.where(`"CodVanzator" = ?`, codVanzator)
+	filter.and(getDateRangeFilter(`"Data"`, dataStart, dataEnd))
 
-			vanzatori = append(
-				vanzatori,
-				repositories.Vanzator{
-					CodVanzator: codVanzator,
-					Nume:        nume,
-					Prenume:     prenume,
-					SalariuBaza: salariuBaza,
-					Comision:    comision,
-					Email:       email,
-					IDAdresa:    IDAdresa,
-				},
-			)
-		}
+	rows, err := client.db.Query(
+		bindPlaceholders(fmt.Sprintf(
+			`SELECT "IdIntrare", TO_CHAR("Data", 'MM/DD/YYYY'), NVL("Total", 0), "CodVanzator", "IdSucursala" FROM "Vanzari%s" %s ORDER BY "IdIntrare"`,
+			client.tableSuffix, filter.whereStatement(),
+		)),
+		filter.args...,
+	)
+	if err != nil {
+		return []repositories.Vanzare{}, err
+	}
 
-		err = rows.Err()
+	defer rows.Close()
+	for rows.Next() {
+		var vanzare repositories.Vanzare
+		err := rows.Scan(&vanzare.IDIntrare, &vanzare.Data, &vanzare.Total, &vanzare.CodVanzator, &vanzare.IDSucursala)
 		if err != nil {
-			return []repositories.Vanzator{}, err
+			return []repositories.Vanzare{}, err
 		}
-		break
-
-	}
 
-	return vanzatori, nil
-}
-
-func (client DBClient) InsertVanzator(vanzatorAdresa repositories.InsertVanzator) error {
-	IDAdresa, err := client.InsertAdresa(vanzatorAdresa.Adresa)
-	if err != nil {
-		return err
+		vanzari = append(vanzari, vanzare)
 	}
 
-	vanzator := vanzatorAdresa.Vanzator
-	stmt, err := client.db.Prepare(fmt.Sprintf(`INSERT INTO "Vanzatori%s"("Nume", "Prenume", "SalariuBaza", "Comision", "EMail", "IdAdresa") VALUES(:1, :2, :3, :4, :5, :6)`, client.tableSuffix))
+	err = rows.Err()
 	if err != nil {
-		return err
+		return []repositories.Vanzare{}, err
 	}
 
-	_, err = stmt.Exec(
-		vanzator.Nume,
-		vanzator.Prenume,
-		vanzator.SalariuBaza,
-		vanzator.Comision,
-		vanzator.Email,
-		IDAdresa,
-	)
-
-	return err
+	return vanzari, nil
 }
 
 func (client DBClient) GetSucursale() ([]repositories.Sucursala, error) {
@@ -1044,35 +1441,51 @@ func (client DBClient) GetSucursale() ([]repositories.Sucursala, error) {
 	return sucursale, nil
 }
 
-func (client DBClient) InsertSucursala(sucursalaAdresa repositories.InsertSucursala, global DBClient) error {
-	IDAdresa, err := global.InsertAdresa(sucursalaAdresa.Adresa)
+// InsertSucursala writes the address on global and every Adrese fragment, and
+// the sucursala on global and on the local site it is routed to, if any,
+// committing every site together.
+func (client DBClient) InsertSucursala(sucursalaAdresa repositories.InsertSucursala, connections Connections) error {
+	IDAdresa, err := nextID(adreseSequence)
 	if err != nil {
 		return err
 	}
-
-	sucursala := sucursalaAdresa.Sucursala
-
-	var IDSucursala int
-	rows, err := global.db.Query(fmt.Sprintf(`SELECT NVL(MAX("IdSucursala"), 0) FROM "Sucursale%s"`, global.tableSuffix))
+	IDSucursala, err := nextID(sucursaleSequence)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-	rows.Next()
-	err = rows.Scan(&IDSucursala)
 
-	stmt, err := client.db.Prepare(fmt.Sprintf(`INSERT INTO "Sucursale%s"("IdSucursala", "NumeSucursala", "IdAdresa") VALUES(:1, :2, :3)`, client.tableSuffix))
-	if err != nil {
-		return err
-	}
+	sucursala := sucursalaAdresa.Sucursala
+	sucursala.IDSucursala = IDSucursala
+	sucursala.IDAdresa = IDAdresa
+	adresa := sucursalaAdresa.Adresa
+	adresa.IDAdresa = IDAdresa
+
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		err := forEachReplica(tx, connections, "Adrese", func(client DBClient, q execer) error {
+			return client.insertFragmentRow(q, "Adrese", getAdresaFields(&adresa))
+		})
+		if err != nil {
+			return err
+		}
 
-	_, err = stmt.Exec(
-		IDSucursala+1,
-		sucursala.NumeSucursala,
-		IDAdresa,
-	)
+		sites := []Store{connections[GlobalConnectionName]}
+		if local, err := GetSucursalaConnection(connections, IDSucursala); err == nil {
+			sites = append(sites, local)
+		}
+		for _, site := range sites {
+			client, q, err := tx.enlist(site)
+			if err != nil {
+				return err
+			}
 
-	return err
+			err = client.insertFragmentRow(q, "Sucursale", getSucursalaFields(&sucursala))
+			if err != nil {
+				return fmt.Errorf("Sucursale on %s: %w", client.name, err)
+			}
+		}
+
+		return nil
+	})
 }
 
 func (client DBClient) GetProiecte() ([]repositories.Proiect, error) {
@@ -1119,13 +1532,125 @@ func (client DBClient) GetProiecte() ([]repositories.Proiect, error) {
 	return proiecte, nil
 }
 
-func (client DBClient) InsertProiect(proiect repositories.Proiect) error {
-	stmt, err := client.db.Prepare(fmt.Sprintf(`INSERT INTO "Proiecte%s"("IdProiect", "NumeProiect", "ValidDeLa", "ValidPanaLa", "Activ") VALUES(:1, :2, TO_DATE(:3, 'MM/DD/YYYY'), TO_DATE(:4, 'MM/DD/YYYY'), :5)`, client.tableSuffix))
+func (client DBClient) GetProiect(IDProiect string) (repositories.Proiect, error) {
+	proiecte, err := client.GetProiecte()
+	if err != nil {
+		return repositories.Proiect{}, err
+	}
+
+	for _, proiect := range proiecte {
+		if proiect.IDProiect == IDProiect {
+			return proiect, nil
+		}
+	}
+
+	return repositories.Proiect{}, fmt.Errorf("proiect %s: %w", IDProiect, ErrNotFound)
+}
+
+// GetLiniiProiect returns the lines of the sales on this site, cancelled ones
+// excepted, attributed to the project.
+func (client DBClient) GetLiniiProiect(IDProiect string) ([]repositories.LinieVanzare, error) {
+	var (
+		linii []repositories.LinieVanzare
+		linie repositories.LinieVanzare
+	)
+
+	rows, err := client.db.Query(
+		fmt.Sprintf(`
+			SELECT lv."IdIntrare", lv."NumarLinie", lv."CodArticol", lv."Cantitate", lv."Pret", lv."Discount", lv."Vat", lv."TotalLinie", lv."IdProiect"
+			FROM "LiniiVanzari%s" lv, "Vanzari%s" v
+			WHERE lv."IdIntrare" = v."IdIntrare" AND lv."IdProiect" = :1 AND v."Status" <> :2
+			ORDER BY lv."IdIntrare", lv."NumarLinie"
+		`, client.tableSuffix, client.tableSuffix),
+		IDProiect,
+		VanzareAnulata,
+	)
+	if err != nil {
+		return []repositories.LinieVanzare{}, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&linie.IDIntrare, &linie.NumarLinie, &linie.CodArticol, &linie.Cantitate, &linie.Pret, &linie.Discount, &linie.VAT, &linie.TotalLinie, &linie.IDProiect)
+		if err != nil {
+			return []repositories.LinieVanzare{}, err
+		}
+
+		linii = append(linii, linie)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return []repositories.LinieVanzare{}, err
+	}
+
+	return linii, nil
+}
+
+// checkProiectLinie refuses a project that does not exist on this site, is not
+// active, or is not valid on the date of the sale. Lines without a project
+// are accepted.
+func (client DBClient) checkProiectLinie(q execer, IDIntrare int, IDProiect string) error {
+	var activ, validDeLa, validPanaLa, dataVanzare sql.NullString
+
+	if len(IDProiect) == 0 {
+		return nil
+	}
+
+	rows, err := q.Query(
+		fmt.Sprintf(`SELECT "Activ", TO_CHAR("ValidDeLa", 'MM/DD/YYYY'), TO_CHAR("ValidPanaLa", 'MM/DD/YYYY') FROM "Proiecte%s" WHERE "IdProiect" = :1`, client.tableSuffix),
+		IDProiect,
+	)
+	if err != nil {
+		return err
+	}
+	found := rows.Next()
+	if found {
+		err = rows.Scan(&activ, &validDeLa, &validPanaLa)
+	} else {
+		err = rows.Err()
+	}
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("proiect %s does not exist: %w", IDProiect, ErrInvalidProiect)
+	}
+	if activ.String != ProiectActiv {
+		return fmt.Errorf("proiect %s is not active: %w", IDProiect, ErrInvalidProiect)
+	}
+
+	rows, err = q.Query(fmt.Sprintf(`SELECT TO_CHAR("Data", 'MM/DD/YYYY') FROM "Vanzari%s" WHERE "IdIntrare" = :1`, client.tableSuffix), IDIntrare)
+	if err != nil {
+		return err
+	}
+	if rows.Next() {
+		err = rows.Scan(&dataVanzare)
+	} else {
+		err = rows.Err()
+	}
+	rows.Close()
 	if err != nil {
 		return err
 	}
 
-	_, err = stmt.Exec(
+	return checkProiectValid(IDProiect, validDeLa.String, validPanaLa.String, dataVanzare.String)
+}
+
+// InsertProiect writes the project on global and on every replica in one
+// distributed transaction, so sale lines on any site can use it.
+func (client DBClient) InsertProiect(proiect repositories.Proiect, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		return forEachReplica(tx, connections, "Proiecte", func(client DBClient, q execer) error {
+			return client.insertProiect(q, proiect)
+		})
+	})
+}
+
+func (client DBClient) insertProiect(q execer, proiect repositories.Proiect) error {
+	_, err := q.Exec(
+		fmt.Sprintf(`INSERT INTO "Proiecte%s"("IdProiect", "NumeProiect", "ValidDeLa", "ValidPanaLa", "Activ") VALUES(:1, :2, TO_DATE(:3, 'MM/DD/YYYY'), TO_DATE(:4, 'MM/DD/YYYY'), :5)`, client.tableSuffix),
 		proiect.IDProiect,
 		proiect.NumeProiect,
 		proiect.ValidDeLa,
@@ -1220,20 +1745,24 @@ func (client DBClient) GetUnitatiDeMasura() ([]repositories.UnitateDeMasura, err
 	return um, nil
 }
 
-func (client DBClient) GetVanzariGrupeArticole() ([]repositories.VanzariGrupeArticole, error) {
+func (client DBClient) GetVanzariGrupeArticole(params repositories.FormParams, coduriParteneri []string) ([]repositories.VanzariGrupeArticole, error) {
 	var (
 		results       []repositories.VanzariGrupeArticole
 		numeGrupa     string
-		vanzareTotala float32
+		vanzareTotala repositories.Money
 	)
 
-	rows, err := client.db.Query(fmt.Sprintf(`
+	filter := getFormParamsFilter(params, coduriParteneri, client.tableSuffix, true)
+	filter.join(fmt.Sprintf(`"LiniiVanzari%s" lv`, client.tableSuffix), `v."IdIntrare" = lv."IdIntrare"`)
+	filter.join(fmt.Sprintf(`"Articole%s" a`, client.tableSuffix), `lv."CodArticol" = a."CodArticol"`)
+	filter.join(fmt.Sprintf(`"GrupaArticole%s" ga`, client.tableSuffix), `a."CodGrupa" = ga."CodGrupa"`)
+
+	rows, err := client.db.Query(bindPlaceholders(fmt.Sprintf(`
 		SELECT NVL(SUM(v."Platit"), 0) VanzareTotala, ga."NumeGrupa"
-		FROM "Vanzari%s" v, "LiniiVanzari%s" lv, "Articole%s" a, "GrupaArticole%s" ga
-		WHERE v."IdIntrare" = lv."IdIntrare" AND lv."CodArticol" = a."CodArticol" AND a."CodGrupa" = ga."CodGrupa"
+		%s
+		%s
 		GROUP BY ga."NumeGrupa"
-		
-	`, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix))
+	`, filter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), filter.whereStatement())), filter.args...)
 	if err != nil {
 		return []repositories.VanzariGrupeArticole{}, err
 	}
@@ -1262,48 +1791,44 @@ func (client DBClient) GetVanzariGrupeArticole() ([]repositories.VanzariGrupeArt
 	return results, nil
 }
 
-func (client DBClient) GetCantitatiJudete() ([]repositories.CantitateJudete, error) {
+// GetCantitatiJudete sums the quantity sold per county and unit of measure.
+// Only global holds the county of every address, so the lines are grouped by
+// branch and judete, read from global, maps each branch to its county.
+func (client DBClient) GetCantitatiJudete(params repositories.FormParams, coduriParteneri []string, judete map[string]string) ([]repositories.CantitateJudete, error) {
 	var (
-		results        []repositories.CantitateJudete
-		judet          string
-		um             string
-		cantitateMedie float32
+		results     []repositories.CantitateJudete
+		idSucursala int
+		um          string
+		cantitate   float32
 	)
 
-	query := fmt.Sprintf(`
-		SELECT (
-			SELECT NVL(AVG(SUM(lv."Cantitate")), 0)
-			FROM "Vanzari%s" v, "LiniiVanzari%s" lv, "Sucursale%s" s, "Adrese%s" ad2, "Articole%s" ar, "UnitatiDeMasura%s" um2 
-			WHERE v."IdIntrare" = lv."IdIntrare" AND v."IdSucursala" = s."IdSucursala" AND s."IdAdresa" = ad2."IdAdresa" AND lv."CodArticol" = ar."CodArticol" AND ar."IdUnitateDeMasura" = um2."IdUnitateDeMasura"
-			AND um2."NumeUnitateDeMasura" = um."NumeUnitateDeMasura" AND ad2."Judet" = ad."Judet"
-			GROUP BY um2."NumeUnitateDeMasura", ad2."Judet"
-		) CantitateMedie, um."NumeUnitateDeMasura", ad."Judet"
-		FROM "Vanzari%s" v, "LiniiVanzari%s" lv, "Sucursale%s" s, "Adrese%s" ad, "Articole%s" ar, "UnitatiDeMasura%s" um
-		WHERE v."IdIntrare" = lv."IdIntrare" AND v."IdSucursala" = s."IdSucursala" AND s."IdAdresa" = ad."IdAdresa" AND lv."CodArticol" = ar."CodArticol" AND ar."IdUnitateDeMasura" = um."IdUnitateDeMasura"
-		GROUP BY um."NumeUnitateDeMasura", ad."Judet"
-	`, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix, client.tableSuffix)
-	fmt.Println(query)
-
-	rows, err := client.db.Query(query)
+	filter := getFormParamsFilter(params, coduriParteneri, client.tableSuffix, true)
+	filter.join(fmt.Sprintf(`"LiniiVanzari%s" lv`, client.tableSuffix), `v."IdIntrare" = lv."IdIntrare"`)
+	filter.join(fmt.Sprintf(`"Articole%s" a`, client.tableSuffix), `lv."CodArticol" = a."CodArticol"`)
+	filter.join(fmt.Sprintf(`"UnitatiDeMasura%s" um`, client.tableSuffix), `a."IdUnitateDeMasura" = um."IdUnitateDeMasura"`)
+
+	rows, err := client.db.Query(bindPlaceholders(fmt.Sprintf(`
+		SELECT NVL(SUM(lv."Cantitate"), 0) Cantitate, um."NumeUnitateDeMasura", v."IdSucursala"
+		%s
+		%s
+		GROUP BY um."NumeUnitateDeMasura", v."IdSucursala"
+	`, filter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), filter.whereStatement())), filter.args...)
 	if err != nil {
 		return []repositories.CantitateJudete{}, err
 	}
 
 	defer rows.Close()
 	for rows.Next() {
-		err := rows.Scan(&cantitateMedie, &um, &judet)
+		err := rows.Scan(&cantitate, &um, &idSucursala)
 		if err != nil {
 			return []repositories.CantitateJudete{}, err
 		}
 
-		results = append(
-			results,
-			repositories.CantitateJudete{
-				Judet:          judet,
-				Um:             um,
-				CantitateMedie: cantitateMedie,
-			},
-		)
+		results = addCantitateJudet(results, repositories.CantitateJudete{
+			Judet:          judete[strconv.Itoa(idSucursala)],
+			Um:             um,
+			CantitateMedie: cantitate,
+		})
 	}
 
 	err = rows.Err()
@@ -1314,43 +1839,46 @@ func (client DBClient) GetCantitatiJudete() ([]repositories.CantitateJudete, err
 	return results, nil
 }
 
-func (client DBClient) GetProcentDiscountTrimestre() ([]repositories.ProcentDiscountTrimestru, error) {
+func (client DBClient) GetProcentDiscountTrimestre(params repositories.FormParams, coduriParteneri []string) ([]repositories.ProcentDiscountTrimestru, error) {
 	var (
 		results         []repositories.ProcentDiscountTrimestru
 		trimestru       string
 		procentDiscount float32
+		numarVanzari    int
 	)
 
+	filter := getFormParamsFilter(params, coduriParteneri, client.tableSuffix, false)
+
 	query := fmt.Sprintf(`
-		SELECT NVL(AVG("Discount" * 100 / NVL("Total", 1)), 0) ProcentDiscount, EXTRACT(YEAR FROM "Data") || '-q' || (
+		SELECT NVL(AVG(v."Discount" * 100 / NVL(v."Total", 1)), 0) ProcentDiscount, COUNT(v."Discount" * 100 / NVL(v."Total", 1)) NumarVanzari, EXTRACT(YEAR FROM v."Data") || '-q' || (
 			CASE 
-				WHEN EXTRACT(MONTH FROM "Data") IN (1, 2, 3) THEN 1
-				WHEN EXTRACT(MONTH FROM "Data") IN (4, 5, 6) THEN 2
-				WHEN EXTRACT(MONTH FROM "Data") IN (7, 8, 9) THEN 3
-				WHEN EXTRACT(MONTH FROM "Data") IN (10, 11, 12) THEN 4
+				WHEN EXTRACT(MONTH FROM v."Data") IN (1, 2, 3) THEN 1
+				WHEN EXTRACT(MONTH FROM v."Data") IN (4, 5, 6) THEN 2
+				WHEN EXTRACT(MONTH FROM v."Data") IN (7, 8, 9) THEN 3
+				WHEN EXTRACT(MONTH FROM v."Data") IN (10, 11, 12) THEN 4
 			END
 		) Trimestru
-		FROM "Vanzari%s"
-		GROUP BY EXTRACT(YEAR FROM "Data") || '-q' || (
+		%s
+		%s
+		GROUP BY EXTRACT(YEAR FROM v."Data") || '-q' || (
 			CASE 
-				WHEN EXTRACT(MONTH FROM "Data") IN (1, 2, 3) THEN 1
-				WHEN EXTRACT(MONTH FROM "Data") IN (4, 5, 6) THEN 2
-				WHEN EXTRACT(MONTH FROM "Data") IN (7, 8, 9) THEN 3
-				WHEN EXTRACT(MONTH FROM "Data") IN (10, 11, 12) THEN 4
+				WHEN EXTRACT(MONTH FROM v."Data") IN (1, 2, 3) THEN 1
+				WHEN EXTRACT(MONTH FROM v."Data") IN (4, 5, 6) THEN 2
+				WHEN EXTRACT(MONTH FROM v."Data") IN (7, 8, 9) THEN 3
+				WHEN EXTRACT(MONTH FROM v."Data") IN (10, 11, 12) THEN 4
 			END
 		)
 		ORDER BY Trimestru
-	`, client.tableSuffix)
-	fmt.Println(query)
+	`, filter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), filter.whereStatement())
 
-	rows, err := client.db.Query(query)
+	rows, err := client.db.Query(bindPlaceholders(query), filter.args...)
 	if err != nil {
 		return []repositories.ProcentDiscountTrimestru{}, err
 	}
 
 	defer rows.Close()
 	for rows.Next() {
-		err := rows.Scan(&procentDiscount, &trimestru)
+		err := rows.Scan(&procentDiscount, &numarVanzari, &trimestru)
 		if err != nil {
 			return []repositories.ProcentDiscountTrimestru{}, err
 		}
@@ -1360,6 +1888,7 @@ func (client DBClient) GetProcentDiscountTrimestre() ([]repositories.ProcentDisc
 			repositories.ProcentDiscountTrimestru{
 				Trimestru:       trimestru,
 				ProcentDiscount: procentDiscount,
+				NumarVanzari:    numarVanzari,
 			},
 		)
 	}
@@ -1372,49 +1901,32 @@ func (client DBClient) GetProcentDiscountTrimestre() ([]repositories.ProcentDisc
 	return results, nil
 }
 
-func (client DBClient) GetCantitateLivrataZile(dataStart string, dataEnd string) ([]repositories.CantitateLivrataZile, error) {
+func (client DBClient) GetCantitateLivrataZile(params repositories.FormParams, coduriParteneri []string) ([]repositories.CantitateLivrataZile, error) {
+	filter, subQueryFilter := getCantitateLivrataZileFilters(params, coduriParteneri, client.tableSuffix)
+
+	query := fmt.Sprintf(`
+		SELECT (
+			SELECT NVL(AVG(SUM(lv."Cantitate")), 0)
+			%s
+			%s
+			GROUP BY TO_CHAR(v."DataLivrare", 'DY')
+		) CantitateMedieLivrata, TO_CHAR(vl."DataLivrare", 'DY') ZiSaptamana
+		FROM (SELECT v."DataLivrare" %s %s) vl
+		GROUP BY TO_CHAR(vl."DataLivrare", 'DY')
+	`, subQueryFilter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), subQueryFilter.whereStatement(),
+		filter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), filter.whereStatement())
+
+	return client.queryCantitateLivrataZile(query, subQueryFilter.and(filter).args)
+}
+
+func (client DBClient) queryCantitateLivrataZile(query string, args []interface{}) ([]repositories.CantitateLivrataZile, error) {
 	var (
 		results               []repositories.CantitateLivrataZile
 		ziSaptamana           string
 		cantitateMedieLivrata float32
 	)
 
-	whereStatement := ""
-	if len(dataStart) > 0 {
-		whereStatement = fmt.Sprintf("%s%s%s", `WHERE v."Data" >= TO_DATE('`, dataStart, `', 'MM/DD/YYYY')`)
-	}
-	if len(dataEnd) > 0 {
-		if len(whereStatement) == 0 {
-			whereStatement = fmt.Sprintf("%s%s%s", `WHERE v."Data" <= TO_DATE('`, dataEnd, `', 'MM/DD/YYYY')`)
-		} else {
-			whereStatement = fmt.Sprintf("%s%s%s%s", whereStatement, ` AND v."Data" <= TO_DATE(`, dataEnd, `, 'MM/DD/YYYY')`)
-		}
-	}
-
-	subQueryWhere := whereStatement
-	subQueryWhere = strings.Replace(subQueryWhere, "v.", "v2.", -1)
-	if len(subQueryWhere) == 0 {
-		subQueryWhere = `WHERE v2."IdIntrare" = lv2."IdIntrare" AND TO_CHAR(v2."DataLivrare", 'DY') = TO_CHAR(v."DataLivrare", 'DY')`
-	} else {
-		subQueryWhere = fmt.Sprintf(`%s AND v2."IdIntrare" = lv2."IdIntrare" AND TO_CHAR(v2."DataLivrare", 'DY') = TO_CHAR(v."DataLivrare", 'DY')`, subQueryWhere)
-	}
-
-	query := fmt.Sprintf("%s\n%s\n%s",
-		fmt.Sprintf(`
-		SELECT (
-			SELECT NVL(AVG(SUM(lv2."Cantitate")), 0)
-			FROM "Vanzari%s" v2, "LiniiVanzari%s" lv2
-			%s
-			GROUP BY TO_CHAR(v2."DataLivrare", 'DY')
-		) CantitateMedieLivrata, TO_CHAR(v."DataLivrare", 'DY') ZiSaptamana
-		FROM "Vanzari%s" v
-		`, client.tableSuffix, client.tableSuffix, subQueryWhere, client.tableSuffix),
-		whereStatement,
-		`GROUP BY TO_CHAR(v."DataLivrare", 'DY')`,
-	)
-	fmt.Println(query)
-
-	rows, err := client.db.Query(query)
+	rows, err := client.db.Query(bindPlaceholders(query), args...)
 	if err != nil {
 		return []repositories.CantitateLivrataZile{}, err
 	}
@@ -1443,25 +1955,38 @@ func (client DBClient) GetCantitateLivrataZile(dataStart string, dataEnd string)
 	return results, nil
 }
 
-func (client DBClient) GetFormReport(params repositories.FormParams) ([]repositories.FormResult, error) {
+// The filtered sales are read in a derived table, vl, so that the subquery can
+// use the aliases of the shared filter and still correlate with the week day
+// of the outer row. Its arguments come first.
+func getCantitateLivrataZileFilters(params repositories.FormParams, coduriParteneri []string, tableSuffix string) (sqlFilter, sqlFilter) {
+	filter := getFormParamsFilter(params, coduriParteneri, tableSuffix, false)
+
+	subQueryFilter := getFormParamsFilter(params, coduriParteneri, tableSuffix, true)
+	subQueryFilter.join(fmt.Sprintf(`"LiniiVanzari%s" lv`, tableSuffix), `v."IdIntrare" = lv."IdIntrare"`)
+	subQueryFilter.where(`TO_CHAR(v."DataLivrare", 'DY') = TO_CHAR(vl."DataLivrare", 'DY')`)
+
+	return filter, subQueryFilter
+}
+
+func (client DBClient) GetFormReport(params repositories.FormParams, coduriParteneri []string) ([]repositories.FormResult, error) {
 	selectStatement := `SELECT SUM(lv."Pret") pret, SUM(lv."Cantitate") cantitate, v."Vat", SUM(lv."Discount") discount, v."Platit", COUNT(*) numarTranzactii`
 	fromStatement := fmt.Sprintf(`FROM "Vanzari%s" v, "LiniiVanzari%s" lv`, client.tableSuffix, client.tableSuffix)
 	groupByStatement := `GROUP BY v."Vat", v."Platit", v."IdIntrare"`
 
-	query, _ := client.getReportQueryBasedOnFormParams(selectStatement, fromStatement, groupByStatement, params)
-	fmt.Println(query)
+	filter := getFormReportFilter(params, coduriParteneri, client.tableSuffix)
+	query := fmt.Sprintf("%s\n%s\n%s\n%s", selectStatement, filter.from(fromStatement), filter.whereStatement(), groupByStatement)
 
 	var (
 		results         []repositories.FormResult
-		pret            float32
-		cantitate       float32
-		vat             float32
-		discount        float32
-		platit          float32
-		numarTranzactii float32
+		pret            repositories.Money
+		cantitate       float64
+		vat             repositories.Money
+		discount        repositories.Money
+		platit          repositories.Money
+		numarTranzactii float64
 	)
 
-	rows, err := client.db.Query(query)
+	rows, err := client.db.Query(bindPlaceholders(query), filter.args...)
 	if err != nil {
 		return []repositories.FormResult{}, err
 	}
@@ -1494,44 +2019,42 @@ func (client DBClient) GetFormReport(params repositories.FormParams) ([]reposito
 	return results, nil
 }
 
-func (client DBClient) GetGroupedFormReport(params repositories.FormParams) ([]repositories.FormResult, error) {
-	placeholder := "{PLACEHOLDER}"
+func (client DBClient) GetGroupedFormReport(params repositories.FormParams, coduriParteneri []string) ([]repositories.FormResult, error) {
+	filter := getFormReportFilter(params, coduriParteneri, client.tableSuffix)
+	fromStatement := fmt.Sprintf(`FROM "Vanzari%s" v, "LiniiVanzari%s" lv`, client.tableSuffix, client.tableSuffix)
 	selectStatement := fmt.Sprintf(`
 		SELECT NVL(SUM(lv."Pret"), 0) PretTotal, NVL(SUM(lv."Cantitate"), 0) CantitateTotal, NVL(SUM(v."Vat"), 0) VatTotal, 
 			NVL(SUM(lv."Discount"), 0) DiscountTotal, NVL(SUM(v."Platit"), 0) PlatitTotal, 
 			NVL(SUM(
-				(SELECT COUNT(*) FROM "Vanzari%s" v, "LiniiVanzari%s" lv %s)
+				(SELECT COUNT(*) %s %s)
 			), 0) NumarTranzactiiTotal,
 			NVL(AVG(lv."Pret"), 0) PretMediu, NVL(AVG(lv."Cantitate"), 0) CantitateMedie, NVL(AVG(v."Vat"), 0) VatMediu, 
 			NVL(AVG(lv."Discount"), 0) DiscountMediu, NVL(AVG(v."Platit"), 0) PlatitMedie, 
 			NVL(AVG(
-				(SELECT COUNT(*) FROM "Vanzari%s" v, "LiniiVanzari%s" lv %s)
+				(SELECT COUNT(*) %s %s)
 			), 0) NumarTranzactiiMediu 
-	`, client.tableSuffix, client.tableSuffix, placeholder, client.tableSuffix, client.tableSuffix, placeholder)
-	fromStatement := fmt.Sprintf(`FROM "Vanzari%s" v, "LiniiVanzari%s" lv`, client.tableSuffix, client.tableSuffix)
+	`, filter.from(fromStatement), filter.whereStatement(), filter.from(fromStatement), filter.whereStatement())
 	groupByStatement := `GROUP BY lv."IdIntrare"`
 
-	query, where := client.getReportQueryBasedOnFormParams(selectStatement, fromStatement, groupByStatement, params)
-	query = strings.Replace(query, placeholder, where, 2)
-	fmt.Println(query)
+	query := fmt.Sprintf("%s\n%s\n%s\n%s", selectStatement, filter.from(fromStatement), filter.whereStatement(), groupByStatement)
 
 	var (
 		results              []repositories.FormResult
-		pretTotal            float32
-		cantitateTotal       float32
-		vatTotal             float32
-		discountTotal        float32
-		platitTotal          float32
-		numarTranzactiiTotal float32
-		pretMediu            float32
-		cantitateMedie       float32
-		vatMediu             float32
-		discountMediu        float32
-		platitMedie          float32
-		numarTranzactiiMediu float32
+		pretTotal            repositories.Money
+		cantitateTotal       float64
+		vatTotal             repositories.Money
+		discountTotal        repositories.Money
+		platitTotal          repositories.Money
+		numarTranzactiiTotal float64
+		pretMediu            repositories.Money
+		cantitateMedie       float64
+		vatMediu             repositories.Money
+		discountMediu        repositories.Money
+		platitMedie          repositories.Money
+		numarTranzactiiMediu float64
 	)
 
-	rows, err := client.db.Query(query)
+	rows, err := client.db.Query(bindPlaceholders(query), repeatArgs(filter.args, 3)...)
 	if err != nil {
 		return []repositories.FormResult{}, err
 	}
@@ -1573,73 +2096,47 @@ func (client DBClient) GetGroupedFormReport(params repositories.FormParams) ([]r
 	return results, nil
 }
 
-func (client DBClient) getReportQueryBasedOnFormParams(selectStatement string, fromStatement string, groupByStatement string, params repositories.FormParams) (string, string) {
-	whereStatement := ""
+func getFormReportFilter(params repositories.FormParams, coduriParteneri []string, tableSuffix string) sqlFilter {
+	filter := getFormParamsFilter(params, coduriParteneri, tableSuffix, true)
+	filter.where(`v."IdIntrare" = lv."IdIntrare"`)
 
-	if params.CodVanzator != 0 {
-		if len(whereStatement) == 0 {
-			whereStatement = "WHERE "
-		} else {
-			whereStatement = fmt.Sprintf("%s AND ", whereStatement)
-		}
-		whereStatement = fmt.Sprintf("%s %s %d", whereStatement, `v."CodVanzator" =`, params.CodVanzator)
-	}
+	return filter
+}
 
-	if len(params.NumeArticol) > 0 {
-		if len(whereStatement) == 0 {
-			whereStatement = "WHERE "
-		} else {
-			whereStatement = fmt.Sprintf("%s AND ", whereStatement)
-		}
-		whereStatement = fmt.Sprintf("%s %s '%s'", whereStatement, `lv."CodArticol" = a."CodArticol" AND a."NumeArticol" =`, params.NumeArticol)
-		fromStatement = fmt.Sprintf(`%s, "Articole%s" a`, fromStatement, client.tableSuffix)
+// runTransaction runs work in one transaction on this site, rolled back
+// completely when work fails.
+func (client DBClient) runTransaction(work func(q execer) error) error {
+	tx, err := client.db.Begin()
+	if err != nil {
+		return err
 	}
 
-	if len(params.NumeSucursala) > 0 {
-		if len(whereStatement) == 0 {
-			whereStatement = "WHERE "
-		} else {
-			whereStatement = fmt.Sprintf("%s AND ", whereStatement)
-		}
-		whereStatement = fmt.Sprintf("%s %s '%s'", whereStatement, `v."IdSucursala" = s."IdSucursala" AND s."NumeSucursala" =`, params.NumeSucursala)
-		fromStatement = fmt.Sprintf(`%s, "Sucursale%s" s`, fromStatement, client.tableSuffix)
+	err = work(tx)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
 	}
 
-	if len(params.NumePartener) > 0 {
-		if len(whereStatement) == 0 {
-			whereStatement = "WHERE "
-		} else {
-			whereStatement = fmt.Sprintf("%s AND ", whereStatement)
-		}
-		whereStatement = fmt.Sprintf("%s %s '%s'", whereStatement, `v."CodPartener" = p."CodPartener" AND p."NumePartener" =`, params.NumePartener)
-		fromStatement = fmt.Sprintf(`%s, "Parteneri%s" p`, fromStatement, client.tableSuffix)
-	}
+	return tx.Commit()
+}
 
-	if len(params.DataStart) > 0 {
-		if len(whereStatement) == 0 {
-			whereStatement = "WHERE "
-		} else {
-			whereStatement = fmt.Sprintf("%s AND ", whereStatement)
-		}
-		whereStatement = fmt.Sprintf("%s %s'%s'%s", whereStatement, `v."Data" >= TO_DATE(`, params.DataStart, `, 'MM/DD/YYYY')`)
+func queryInt(q execer, query string, args ...interface{}) (int, error) {
+	var value int
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return 0, err
 	}
+	defer rows.Close()
 
-	if len(params.DataEnd) > 0 {
-		if len(whereStatement) == 0 {
-			whereStatement = "WHERE "
-		} else {
-			whereStatement = fmt.Sprintf("%s AND ", whereStatement)
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return 0, err
 		}
-		whereStatement = fmt.Sprintf("%s %s'%s'%s", whereStatement, `v."Data" <= TO_DATE(`, params.DataEnd, `, 'MM/DD/YYYY')`)
-	}
-
-	if len(whereStatement) == 0 {
-		whereStatement = `WHERE v."IdIntrare" = lv."IdIntrare"`
-	} else {
-		whereStatement = fmt.Sprintf(`%s AND v."IdIntrare" = lv."IdIntrare"`, whereStatement)
+		return 0, sql.ErrNoRows
 	}
+	err = rows.Scan(&value)
 
-	return fmt.Sprintf("%s\n%s\n%s\n%s", selectStatement, fromStatement, whereStatement, groupByStatement), whereStatement
+	return value, err
 }
 
 func getTableSuffix(connectionName string) string {
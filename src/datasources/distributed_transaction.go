@@ -0,0 +1,528 @@
+package datasources
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	commitDecision = "commit"
+	doneDecision   = "done"
+)
+
+type (
+	loggedStatement struct {
+		Query string        `json:"query"`
+		Args  []interface{} `json:"args"`
+	}
+
+	loggedParticipant struct {
+		Site       string            `json:"site"`
+		Statements []loggedStatement `json:"statements"`
+	}
+
+	transactionLogRecord struct {
+		ID           string              `json:"id"`
+		Decision     string              `json:"decision"`
+		Participants []loggedParticipant `json:"participants,omitempty"`
+	}
+
+	// participant is the local transaction opened on one database. Sites that
+	// share a database, as the SQLite sites do, share a participant.
+	participant struct {
+		client     DBClient
+		tx         transaction
+		statements []loggedStatement
+	}
+
+	// participantTx runs statements in the participant's transaction and keeps
+	// the writes so they can be redone on recovery.
+	participantTx struct {
+		participant *participant
+	}
+
+	participantStore interface {
+		sqlClient() DBClient
+	}
+
+	// distributedTransaction coordinates a write spanning several sites with a
+	// two-phase commit: every participant first records a marker row inside its
+	// own transaction (prepare), then the commit decision is forced to the
+	// transaction log before any participant commits. A transaction without a
+	// decision record is presumed aborted; one with a decision record but no
+	// done record is redone by RecoverTransactions on every participant that is
+	// missing its marker.
+	distributedTransaction struct {
+		id           string
+		participants []*participant
+		byDatabase   map[database]*participant
+	}
+)
+
+var (
+	transactionLog      *os.File
+	transactionLogPath  string
+	transactionLogMutex sync.Mutex
+	transactionCounter  uint64
+
+	// openTransactions holds the commit records without a done record, the
+	// only ones the log has to keep.
+	openTransactions []transactionLogRecord
+)
+
+func OpenTransactionLog(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	transactionLogMutex.Lock()
+	defer transactionLogMutex.Unlock()
+	transactionLog = file
+	transactionLogPath = path
+	openTransactions = nil
+
+	return nil
+}
+
+// RecoverTransactions completes the transactions left in doubt by a crash
+// between the commit decision and the last participant commit, then truncates
+// the log once nothing is pending. While running, the log is also compacted
+// after every transaction completed on all its participants.
+func RecoverTransactions(connections Connections) error {
+	pending, err := readPendingTransactions()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range pending {
+		for _, loggedParticipant := range record.Participants {
+			store, ok := connections[loggedParticipant.Site]
+			if !ok {
+				return fmt.Errorf("no connection configured for site %s of transaction %s", loggedParticipant.Site, record.ID)
+			}
+
+			client, err := getSQLClient(store)
+			if err != nil {
+				return err
+			}
+
+			err = client.completeTransaction(record.ID, loggedParticipant.Statements)
+			if err != nil {
+				return fmt.Errorf("could not complete transaction %s on %s: %w", record.ID, loggedParticipant.Site, err)
+			}
+		}
+
+		err = writeTransactionLogRecord(transactionLogRecord{ID: record.ID, Decision: doneDecision})
+		if err != nil {
+			return err
+		}
+
+		for _, loggedParticipant := range record.Participants {
+			client, _ := getSQLClient(connections[loggedParticipant.Site])
+			client.deleteTransactionMarker(record.ID)
+		}
+	}
+
+	return truncateTransactionLog()
+}
+
+func runDistributedTransaction(work func(tx *distributedTransaction) error) error {
+	tx := beginDistributedTransaction()
+
+	err := work(tx)
+	if err != nil {
+		tx.rollback()
+		return err
+	}
+
+	return tx.commit()
+}
+
+func beginDistributedTransaction() *distributedTransaction {
+	return &distributedTransaction{
+		id:         fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddUint64(&transactionCounter, 1)),
+		byDatabase: make(map[database]*participant),
+	}
+}
+
+func (tx *distributedTransaction) enlist(store Store) (DBClient, execer, error) {
+	client, err := getSQLClient(store)
+	if err != nil {
+		return DBClient{}, nil, err
+	}
+
+	p, ok := tx.byDatabase[client.db]
+	if !ok {
+		dbTx, err := client.db.Begin()
+		if err != nil {
+			return DBClient{}, nil, fmt.Errorf("could not begin transaction on %s: %w", client.name, err)
+		}
+
+		p = &participant{client: client, tx: dbTx}
+		tx.byDatabase[client.db] = p
+		tx.participants = append(tx.participants, p)
+	}
+
+	return client, participantTx{participant: p}, nil
+}
+
+func (tx *distributedTransaction) commit() error {
+	writers := tx.getWriters()
+
+	// Read only participants take no part in the outcome.
+	for _, p := range tx.participants {
+		if len(p.statements) == 0 {
+			_ = p.tx.Rollback()
+		}
+	}
+	if len(writers) == 0 {
+		return nil
+	}
+
+	for _, p := range writers {
+		err := p.client.insertTransactionMarker(p.tx, tx.id)
+		if err != nil {
+			tx.rollbackWriters(writers)
+			return fmt.Errorf("could not prepare transaction on %s: %w", p.client.name, err)
+		}
+	}
+
+	record := transactionLogRecord{ID: tx.id, Decision: commitDecision}
+	for _, p := range writers {
+		record.Participants = append(record.Participants, loggedParticipant{Site: p.client.name, Statements: p.statements})
+	}
+	err := writeTransactionLogRecord(record)
+	if err != nil {
+		tx.rollbackWriters(writers)
+		return fmt.Errorf("could not log commit decision: %w", err)
+	}
+
+	var inDoubt []string
+	for _, p := range writers {
+		err := p.tx.Commit()
+		if err == nil {
+			continue
+		}
+
+		// The commit may have reached the database before failing.
+		err = p.client.completeTransaction(tx.id, p.statements)
+		if err != nil {
+			inDoubt = append(inDoubt, p.client.name)
+		}
+	}
+	if len(inDoubt) > 0 {
+		return fmt.Errorf("transaction %s is in doubt on %v and will be completed on recovery", tx.id, inDoubt)
+	}
+
+	err = writeTransactionLogRecord(transactionLogRecord{ID: tx.id, Decision: doneDecision})
+	if err != nil {
+		// Every participant committed; the markers stay so recovery finds them.
+		return nil
+	}
+
+	for _, p := range writers {
+		p.client.deleteTransactionMarker(tx.id)
+	}
+
+	return nil
+}
+
+func (tx *distributedTransaction) rollback() {
+	for _, p := range tx.participants {
+		_ = p.tx.Rollback()
+	}
+}
+
+func (tx *distributedTransaction) rollbackWriters(writers []*participant) {
+	for _, p := range writers {
+		_ = p.tx.Rollback()
+	}
+}
+
+func (tx *distributedTransaction) getWriters() []*participant {
+	var writers []*participant
+	for _, p := range tx.participants {
+		if len(p.statements) > 0 {
+			writers = append(writers, p)
+		}
+	}
+
+	return writers
+}
+
+func (ptx participantTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return ptx.participant.tx.Query(query, args...)
+}
+
+func (ptx participantTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	result, err := ptx.participant.tx.Exec(query, args...)
+	if err != nil {
+		return result, err
+	}
+
+	ptx.participant.statements = append(ptx.participant.statements, loggedStatement{Query: query, Args: args})
+
+	return result, nil
+}
+
+func getSQLClient(store Store) (DBClient, error) {
+	s, ok := store.(participantStore)
+	if !ok {
+		return DBClient{}, fmt.Errorf("store %T cannot take part in a distributed transaction", store)
+	}
+
+	return s.sqlClient(), nil
+}
+
+func (client DBClient) insertTransactionMarker(q execer, ID string) error {
+	_, err := q.Exec(fmt.Sprintf(`INSERT INTO "TranzactiiDistribuite%s"("IdTranzactie") VALUES(:1)`, client.tableSuffix), ID)
+
+	return err
+}
+
+// deleteTransactionMarker is best effort, a marker left behind only means the
+// transaction is known to be committed on this site.
+func (client DBClient) deleteTransactionMarker(ID string) {
+	_, _ = client.db.Exec(fmt.Sprintf(`DELETE FROM "TranzactiiDistribuite%s" WHERE "IdTranzactie" = :1`, client.tableSuffix), ID)
+}
+
+func (client DBClient) hasTransactionMarker(ID string) (bool, error) {
+	count, err := queryInt(client.db, fmt.Sprintf(`SELECT COUNT(*) FROM "TranzactiiDistribuite%s" WHERE "IdTranzactie" = :1`, client.tableSuffix), ID)
+
+	return count > 0, err
+}
+
+// completeTransaction redoes the logged statements of transaction ID on this
+// site unless its marker shows they were already committed.
+func (client DBClient) completeTransaction(ID string, statements []loggedStatement) error {
+	committed, err := client.hasTransactionMarker(ID)
+	if err != nil || committed {
+		return err
+	}
+
+	return client.redoTransaction(ID, statements)
+}
+
+func (client DBClient) redoTransaction(ID string, statements []loggedStatement) error {
+	tx, err := client.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		_, err = tx.Exec(statement.Query, statement.Args...)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	err = client.insertTransactionMarker(tx, ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func writeTransactionLogRecord(record transactionLogRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	transactionLogMutex.Lock()
+	defer transactionLogMutex.Unlock()
+
+	if transactionLog == nil {
+		return errors.New("transaction log is not open")
+	}
+
+	_, err = transactionLog.Write(append(line, '\n'))
+	if err != nil {
+		return err
+	}
+
+	err = transactionLog.Sync()
+	if err != nil {
+		return err
+	}
+
+	switch record.Decision {
+	case commitDecision:
+		openTransactions = append(openTransactions, record)
+	case doneDecision:
+		for i, open := range openTransactions {
+			if open.ID == record.ID {
+				openTransactions = append(openTransactions[:i], openTransactions[i+1:]...)
+				break
+			}
+		}
+
+		return compactTransactionLog()
+	}
+
+	return nil
+}
+
+// compactTransactionLog drops the records of the transactions completed on
+// every participant. The log is emptied when none is left open; otherwise the
+// open ones are written to a new file that replaces the log, so a crash
+// midway leaves either the old log or the new one.
+func compactTransactionLog() error {
+	if len(openTransactions) == 0 {
+		err := transactionLog.Truncate(0)
+		if err != nil {
+			return err
+		}
+
+		return transactionLog.Sync()
+	}
+
+	compactedPath := transactionLogPath + ".compact"
+	compacted, err := os.OpenFile(compactedPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	for _, record := range openTransactions {
+		line, err := json.Marshal(record)
+		if err == nil {
+			_, err = compacted.Write(append(line, '\n'))
+		}
+		if err != nil {
+			_ = compacted.Close()
+			return err
+		}
+	}
+	err = compacted.Sync()
+	if err != nil {
+		_ = compacted.Close()
+		return err
+	}
+	err = compacted.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(compactedPath, transactionLogPath)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(transactionLogPath, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_ = transactionLog.Close()
+	transactionLog = file
+
+	return nil
+}
+
+func readPendingTransactions() ([]transactionLogRecord, error) {
+	transactionLogMutex.Lock()
+	defer transactionLogMutex.Unlock()
+
+	if transactionLog == nil {
+		return nil, errors.New("transaction log is not open")
+	}
+
+	_, err := transactionLog.Seek(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pending []transactionLogRecord
+		indexes = make(map[string]int)
+	)
+	scanner := bufio.NewScanner(transactionLog)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		record, err := decodeTransactionLogRecord(scanner.Bytes())
+		if err != nil {
+			// A torn last line means the decision never reached the disk.
+			continue
+		}
+
+		switch record.Decision {
+		case commitDecision:
+			indexes[record.ID] = len(pending)
+			pending = append(pending, record)
+		case doneDecision:
+			if index, ok := indexes[record.ID]; ok {
+				pending[index].ID = ""
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var unresolved []transactionLogRecord
+	for _, record := range pending {
+		if len(record.ID) > 0 {
+			unresolved = append(unresolved, record)
+		}
+	}
+	openTransactions = append([]transactionLogRecord(nil), unresolved...)
+
+	return unresolved, nil
+}
+
+func decodeTransactionLogRecord(line []byte) (transactionLogRecord, error) {
+	var record transactionLogRecord
+
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	err := decoder.Decode(&record)
+	if err != nil {
+		return transactionLogRecord{}, err
+	}
+
+	for i := range record.Participants {
+		for j := range record.Participants[i].Statements {
+			args := record.Participants[i].Statements[j].Args
+			for k, arg := range args {
+				if number, ok := arg.(json.Number); ok {
+					args[k] = decodeLoggedNumber(number)
+				}
+			}
+		}
+	}
+
+	return record, nil
+}
+
+func decodeLoggedNumber(number json.Number) interface{} {
+	if value, err := strconv.ParseInt(number.String(), 10, 64); err == nil {
+		return value
+	}
+
+	value, _ := number.Float64()
+
+	return value
+}
+
+func truncateTransactionLog() error {
+	transactionLogMutex.Lock()
+	defer transactionLogMutex.Unlock()
+
+	err := transactionLog.Truncate(0)
+	if err != nil {
+		return err
+	}
+
+	return transactionLog.Sync()
+}
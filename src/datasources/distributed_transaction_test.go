@@ -0,0 +1,127 @@
+package datasources
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransactionLogCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "transactions.log")
+	err = OpenTransactionLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transactionLog.Close()
+
+	steps := []struct {
+		record      transactionLogRecord
+		wantPending []string
+	}{
+		{record: transactionLogRecord{ID: "a", Decision: commitDecision, Participants: []loggedParticipant{{Site: "global"}}}, wantPending: []string{"a"}},
+		{record: transactionLogRecord{ID: "b", Decision: commitDecision, Participants: []loggedParticipant{{Site: "local1"}}}, wantPending: []string{"a", "b"}},
+		{record: transactionLogRecord{ID: "a", Decision: doneDecision}, wantPending: []string{"b"}},
+		{record: transactionLogRecord{ID: "c", Decision: commitDecision}, wantPending: []string{"b", "c"}},
+		{record: transactionLogRecord{ID: "c", Decision: doneDecision}, wantPending: []string{"b"}},
+		{record: transactionLogRecord{ID: "b", Decision: doneDecision}},
+	}
+
+	for _, step := range steps {
+		err := writeTransactionLogRecord(step.record)
+		if err != nil {
+			t.Fatalf("%s %s: %v", step.record.Decision, step.record.ID, err)
+		}
+
+		pending, err := readPendingTransactions()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, record := range pending {
+			got = append(got, record.ID)
+		}
+		if len(got) != len(step.wantPending) {
+			t.Fatalf("after %s %s: pending %v, want %v", step.record.Decision, step.record.ID, got, step.wantPending)
+		}
+		for i := range got {
+			if got[i] != step.wantPending[i] {
+				t.Fatalf("after %s %s: pending %v, want %v", step.record.Decision, step.record.ID, got, step.wantPending)
+			}
+		}
+	}
+
+	if lines := readLogLines(t, path); lines != 0 {
+		t.Errorf("log has %d lines once every transaction is done, want 0", lines)
+	}
+}
+
+func TestTransactionLogCompactionKeepsOpenRecords(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "transactions.log")
+	err = OpenTransactionLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer transactionLog.Close()
+
+	for _, record := range []transactionLogRecord{
+		{ID: "a", Decision: commitDecision, Participants: []loggedParticipant{{Site: "local1", Statements: []loggedStatement{{Query: "UPDATE x", Args: []interface{}{int64(1), "y"}}}}}},
+		{ID: "b", Decision: commitDecision},
+		{ID: "b", Decision: doneDecision},
+	} {
+		err := writeTransactionLogRecord(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if lines := readLogLines(t, path); lines != 1 {
+		t.Fatalf("log has %d lines, want only the open transaction", lines)
+	}
+
+	// A restart reads the compacted log back.
+	transactionLog.Close()
+	err = OpenTransactionLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, err := readPendingTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != "a" {
+		t.Fatalf("pending %+v, want transaction a", pending)
+	}
+	args := pending[0].Participants[0].Statements[0].Args
+	if len(args) != 2 || args[0] != int64(1) || args[1] != "y" {
+		t.Errorf("args %#v, want 1 and y", args)
+	}
+}
+
+func readLogLines(t *testing.T, path string) int {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := 0
+	for _, b := range content {
+		if b == '\n' {
+			lines++
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,35 @@
+package datasources
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound          = errors.New("not found")
+	ErrReferenced        = errors.New("still referenced")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrVanzareAnulata    = errors.New("vanzare is cancelled")
+	ErrPriceMismatch     = errors.New("total does not match its parts")
+	ErrInvalidTransition = errors.New("status transition is not allowed")
+	ErrInvalidCantitate  = errors.New("invalid quantity")
+	ErrInvalidProiect    = errors.New("proiect cannot be used")
+	ErrInvalidFields     = errors.New("invalid fields")
+	ErrInvalidQuery      = errors.New("invalid query parameters")
+)
+
+// LinieVanzareError reports the line of a sale that could not be written; the
+// whole sale is rolled back. Linie is the 1-based position in the request.
+type LinieVanzareError struct {
+	Linie      int
+	CodArticol string
+	Err        error
+}
+
+func (e LinieVanzareError) Error() string {
+	return fmt.Sprintf("linia %d (articol %s): %s", e.Linie, e.CodArticol, e.Err.Error())
+}
+
+func (e LinieVanzareError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,188 @@
+package datasources
+
+import (
+	"sort"
+
+	"modbSalesApp/src/repositories"
+)
+
+// The report form endpoints read the sales of each of the given sites and
+// merge the results. NumePartener is filtered on the codes of the partners
+// with that name, looked up on global, as the names are not held on every
+// site.
+
+func GetVanzariGrupeArticole(connections Connections, sites []Store, params repositories.FormParams) ([]repositories.VanzariGrupeArticole, error) {
+	coduriParteneri, err := getCoduriParteneri(connections[GlobalConnectionName], params.NumePartener)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []repositories.VanzariGrupeArticole
+	indexes := make(map[string]int)
+	for _, site := range sites {
+		grupe, err := site.GetVanzariGrupeArticole(params, coduriParteneri)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, grupa := range grupe {
+			index, ok := indexes[grupa.NumeGrupa]
+			if !ok {
+				indexes[grupa.NumeGrupa] = len(results)
+				results = append(results, grupa)
+				continue
+			}
+
+			vanzareTotala, err := results[index].VanzareTotala.Add(grupa.VanzareTotala)
+			if err != nil {
+				return nil, err
+			}
+			results[index].VanzareTotala = vanzareTotala
+		}
+	}
+
+	return results, nil
+}
+
+func GetCantitatiJudete(connections Connections, sites []Store, params repositories.FormParams) ([]repositories.CantitateJudete, error) {
+	coduriParteneri, err := getCoduriParteneri(connections[GlobalConnectionName], params.NumePartener)
+	if err != nil {
+		return nil, err
+	}
+	judete, err := getJudeteSucursale(connections[GlobalConnectionName])
+	if err != nil {
+		return nil, err
+	}
+
+	var results []repositories.CantitateJudete
+	for _, site := range sites {
+		cantitati, err := site.GetCantitatiJudete(params, coduriParteneri, judete)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cantitate := range cantitati {
+			results = addCantitateJudet(results, cantitate)
+		}
+	}
+
+	return results, nil
+}
+
+// addCantitateJudet adds the quantity to the row of the same county and unit
+// of measure, or appends it when there is none.
+func addCantitateJudet(results []repositories.CantitateJudete, cantitate repositories.CantitateJudete) []repositories.CantitateJudete {
+	for i := range results {
+		if results[i].Judet == cantitate.Judet && results[i].Um == cantitate.Um {
+			results[i].CantitateMedie += cantitate.CantitateMedie
+			return results
+		}
+	}
+
+	return append(results, cantitate)
+}
+
+// GetProcentDiscountTrimestre weighs the average discount of each site by the
+// number of sales it was computed from.
+func GetProcentDiscountTrimestre(connections Connections, sites []Store, params repositories.FormParams) ([]repositories.ProcentDiscountTrimestru, error) {
+	coduriParteneri, err := getCoduriParteneri(connections[GlobalConnectionName], params.NumePartener)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []repositories.ProcentDiscountTrimestru
+	indexes := make(map[string]int)
+	for _, site := range sites {
+		trimestre, err := site.GetProcentDiscountTrimestre(params, coduriParteneri)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, trimestru := range trimestre {
+			index, ok := indexes[trimestru.Trimestru]
+			if !ok {
+				indexes[trimestru.Trimestru] = len(results)
+				results = append(results, trimestru)
+				continue
+			}
+
+			numarVanzari := results[index].NumarVanzari + trimestru.NumarVanzari
+			if numarVanzari > 0 {
+				results[index].ProcentDiscount = (results[index].ProcentDiscount*float32(results[index].NumarVanzari) +
+					trimestru.ProcentDiscount*float32(trimestru.NumarVanzari)) / float32(numarVanzari)
+			}
+			results[index].NumarVanzari = numarVanzari
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Trimestru < results[j].Trimestru
+	})
+
+	return results, nil
+}
+
+func GetCantitateLivrataZile(connections Connections, sites []Store, params repositories.FormParams) ([]repositories.CantitateLivrataZile, error) {
+	coduriParteneri, err := getCoduriParteneri(connections[GlobalConnectionName], params.NumePartener)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []repositories.CantitateLivrataZile
+	indexes := make(map[string]int)
+	for _, site := range sites {
+		zile, err := site.GetCantitateLivrataZile(params, coduriParteneri)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, zi := range zile {
+			index, ok := indexes[zi.ZiSaptamana]
+			if !ok {
+				indexes[zi.ZiSaptamana] = len(results)
+				results = append(results, zi)
+				continue
+			}
+
+			results[index].VolumMediuLivrat += zi.VolumMediuLivrat
+		}
+	}
+
+	return results, nil
+}
+
+func GetFormReport(connections Connections, sites []Store, params repositories.FormParams) ([]repositories.FormResult, error) {
+	coduriParteneri, err := getCoduriParteneri(connections[GlobalConnectionName], params.NumePartener)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []repositories.FormResult
+	for _, site := range sites {
+		formReport, err := site.GetFormReport(params, coduriParteneri)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, formReport...)
+	}
+
+	return results, nil
+}
+
+func GetGroupedFormReport(connections Connections, sites []Store, params repositories.FormParams) ([]repositories.FormResult, error) {
+	coduriParteneri, err := getCoduriParteneri(connections[GlobalConnectionName], params.NumePartener)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []repositories.FormResult
+	for _, site := range sites {
+		formReport, err := site.GetGroupedFormReport(params, coduriParteneri)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, formReport...)
+	}
+
+	return results, nil
+}
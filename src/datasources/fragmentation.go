@@ -0,0 +1,351 @@
+package datasources
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"modbSalesApp/src/repositories"
+)
+
+type (
+	// fragment describes what a site holds of a global table: a vertical
+	// projection on columns and a horizontal selection on predicate, written
+	// against the global tables. Empty values mean all columns and all rows.
+	fragment struct {
+		columns   []string
+		predicate string
+	}
+
+	// Tables with a predicateTemplate are split horizontally by branch: the
+	// predicate of each site is the template applied to the IdSucursala values
+	// routed to it (%[1]s) and to the suffix of the tables it is evaluated on
+	// (%[2]s), see SetSucursalaSites. Tables marked localOnly are written only
+	// on the local sites, so global holds no copy of their rows.
+	tableFragmentation struct {
+		key               []string
+		columns           []string
+		fragments         map[string]fragment
+		predicateTemplate string
+		localOnly         bool
+	}
+)
+
+var fragmentationCatalog = map[string]tableFragmentation{
+	"Parteneri": {
+		key:     []string{"CodPartener"},
+		columns: []string{"CodPartener", "NumePartener", "CUI", "EMail", "IdAdresa"},
+		fragments: map[string]fragment{
+			Local1ConnectionName: {columns: []string{"CodPartener", "NumePartener", "IdAdresa"}},
+			Local2ConnectionName: {columns: []string{"CodPartener", "CUI", "IdAdresa"}},
+			Local3ConnectionName: {columns: []string{"CodPartener", "EMail", "IdAdresa"}},
+			Local4ConnectionName: {columns: []string{"CodPartener", "IdAdresa"}},
+		},
+	},
+	"Adrese": {
+		key:     []string{"IdAdresa"},
+		columns: []string{"IdAdresa", "NumeAdresa", "Oras", "Judet", "Sector", "Strada", "Numar", "Bloc", "Etaj"},
+		fragments: map[string]fragment{
+			Local1ConnectionName: {columns: []string{"IdAdresa", "NumeAdresa"}},
+			Local2ConnectionName: {columns: []string{"IdAdresa", "Oras"}},
+			Local3ConnectionName: {columns: []string{"IdAdresa", "Judet"}},
+			Local4ConnectionName: {columns: []string{"IdAdresa", "Sector", "Strada", "Numar", "Bloc", "Etaj"}},
+		},
+	},
+	"Vanzatori": {
+		key:     []string{"CodVanzator"},
+		columns: []string{"CodVanzator", "Nume", "Prenume", "SalariuBaza", "Comision", "EMail", "IdAdresa", "Activ"},
+		fragments: map[string]fragment{
+			Local1ConnectionName: {columns: []string{"CodVanzator", "Nume", "Prenume", "IdAdresa"}},
+			Local2ConnectionName: {columns: []string{"CodVanzator", "SalariuBaza", "IdAdresa"}},
+			Local3ConnectionName: {columns: []string{"CodVanzator", "Comision", "IdAdresa"}},
+			Local4ConnectionName: {columns: []string{"CodVanzator", "EMail"}},
+		},
+	},
+	"Sucursale": {
+		key:               []string{"IdSucursala"},
+		columns:           []string{"IdSucursala", "NumeSucursala", "IdAdresa"},
+		fragments:         getReplicatedFragments(),
+		predicateTemplate: `"IdSucursala" IN (%[1]s)`,
+	},
+	"Vanzari": {
+		key:               []string{"IdIntrare"},
+		columns:           []string{"IdIntrare", "CodPartener", "Status", "Data", "DataLivrare", "Total", "Vat", "Discount", "Moneda", "Platit", "Comentarii", "CodVanzator", "IdSucursala"},
+		fragments:         getReplicatedFragments(),
+		predicateTemplate: `"IdSucursala" IN (%[1]s)`,
+		localOnly:         true,
+	},
+	"LiniiVanzari": {
+		key:               []string{"IdIntrare", "NumarLinie"},
+		columns:           []string{"IdIntrare", "NumarLinie", "CodArticol", "Cantitate", "Pret", "Discount", "Vat", "TotalLinie", "IdProiect", "StocScazut"},
+		fragments:         getReplicatedFragments(),
+		predicateTemplate: `"IdIntrare" IN (SELECT "IdIntrare" FROM "Vanzari%[2]s" WHERE "IdSucursala" IN (%[1]s))`,
+		localOnly:         true,
+	},
+	"Articole": {
+		key:       []string{"CodArticol"},
+		columns:   []string{"CodArticol", "NumeArticol", "CodGrupa", "CantitateStoc", "IdUnitateDeMasura"},
+		fragments: getReplicatedFragments(),
+	},
+	"Proiecte": {
+		key:       []string{"IdProiect"},
+		columns:   []string{"IdProiect", "NumeProiect", "ValidDeLa", "ValidPanaLa", "Activ"},
+		fragments: getReplicatedFragments(),
+	},
+	"GrupaArticole": {
+		key:       []string{"CodGrupa"},
+		columns:   []string{"CodGrupa", "NumeGrupa", "DetaliiGrupa"},
+		fragments: getReplicatedFragments(),
+	},
+	"UnitatiDeMasura": {
+		key:       []string{"IdUnitateDeMasura"},
+		columns:   []string{"IdUnitateDeMasura", "NumeUnitateDeMasura", "Inaltime", "Latime", "Lungime"},
+		fragments: getReplicatedFragments(),
+	},
+}
+
+var columnJSONNames = map[string]string{
+	"EMail":             "Email",
+	"IdAdresa":          "IDAdresa",
+	"IdIntrare":         "IDIntrare",
+	"IdSucursala":       "IDSucursala",
+	"IdProiect":         "IDProiect",
+	"IdUnitateDeMasura": "IDUnitateMasura",
+	"Vat":               "VAT",
+}
+
+func getReplicatedFragments() map[string]fragment {
+	return map[string]fragment{
+		Local1ConnectionName: {},
+		Local2ConnectionName: {},
+		Local3ConnectionName: {},
+		Local4ConnectionName: {},
+	}
+}
+
+func getFragmentColumns(table string, connectionName string) []string {
+	fragmentation := fragmentationCatalog[table]
+	if f, ok := fragmentation.fragments[connectionName]; ok && len(f.columns) > 0 {
+		return f.columns
+	}
+
+	return fragmentation.columns
+}
+
+func getFragmentPredicate(table string, connectionName string) string {
+	fragmentation := fragmentationCatalog[table]
+	if len(fragmentation.predicateTemplate) > 0 && connectionName != GlobalConnectionName {
+		return getSucursalaPredicate(fragmentation.predicateTemplate, connectionName, "")
+	}
+
+	return fragmentation.fragments[connectionName].predicate
+}
+
+func getVerticalFragmentSites(table string) []string {
+	var sites []string
+	for _, connectionName := range connectionNames {
+		if f, ok := fragmentationCatalog[table].fragments[connectionName]; ok && len(f.columns) > 0 {
+			sites = append(sites, connectionName)
+		}
+	}
+
+	return sites
+}
+
+// forEachReplica enlists global and every site holding a fragment of table in
+// tx and runs work on each of them, global first.
+func forEachReplica(tx *distributedTransaction, connections Connections, table string, work func(client DBClient, q execer) error) error {
+	for _, connectionName := range connectionNames {
+		if _, ok := fragmentationCatalog[table].fragments[connectionName]; !ok && connectionName != GlobalConnectionName {
+			continue
+		}
+
+		store, ok := connections[connectionName]
+		if !ok {
+			return fmt.Errorf("no connection configured for site %s", connectionName)
+		}
+		client, q, err := tx.enlist(store)
+		if err != nil {
+			return err
+		}
+
+		err = work(client, q)
+		if err != nil {
+			return fmt.Errorf("%s on %s: %w", table, connectionName, err)
+		}
+	}
+
+	return nil
+}
+
+// insertFragmentRow inserts the columns of fields that the fragment of table on
+// this site holds.
+func (client DBClient) insertFragmentRow(q execer, table string, fields map[string]interface{}) error {
+	columns := getFragmentColumns(table, client.name)
+	placeholders := make([]string, len(columns))
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		placeholders[i] = fmt.Sprintf(":%d", i+1)
+		values[i] = fields[column]
+	}
+
+	_, err := q.Exec(
+		fmt.Sprintf(`INSERT INTO "%s%s"(%s) VALUES(%s)`, table, client.tableSuffix, strings.Join(quoteColumns(columns), ", "), strings.Join(placeholders, ", ")),
+		values...,
+	)
+
+	return err
+}
+
+// updateFragmentRow sets the columns of fields that the fragment of table on
+// this site holds, key columns excepted, on the row with the key in fields; a
+// fragment holding none of them is left alone.
+func (client DBClient) updateFragmentRow(q execer, table string, fields map[string]interface{}) error {
+	key := fragmentationCatalog[table].key
+
+	var assignments []string
+	var values []interface{}
+	for _, column := range getFragmentColumns(table, client.name) {
+		value, ok := fields[column]
+		if !ok || containsString(key, column) {
+			continue
+		}
+		values = append(values, value)
+		assignments = append(assignments, fmt.Sprintf(`"%s" = :%d`, column, len(values)))
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	condition, keyValues := getFragmentKeyCondition(table, fields, len(values))
+	_, err := q.Exec(
+		fmt.Sprintf(`UPDATE "%s%s" SET %s WHERE %s`, table, client.tableSuffix, strings.Join(assignments, ", "), condition),
+		append(values, keyValues...)...,
+	)
+
+	return err
+}
+
+// deleteFragmentRow deletes the row with the key in fields from the fragment of
+// table on this site.
+func (client DBClient) deleteFragmentRow(q execer, table string, fields map[string]interface{}) error {
+	condition, keyValues := getFragmentKeyCondition(table, fields, 0)
+	_, err := q.Exec(fmt.Sprintf(`DELETE FROM "%s%s" WHERE %s`, table, client.tableSuffix, condition), keyValues...)
+
+	return err
+}
+
+func getFragmentKeyCondition(table string, fields map[string]interface{}, bound int) (string, []interface{}) {
+	key := fragmentationCatalog[table].key
+	conditions := make([]string, len(key))
+	values := make([]interface{}, len(key))
+	for i, column := range key {
+		conditions[i] = fmt.Sprintf(`"%s" = :%d`, column, bound+i+1)
+		values[i] = fields[column]
+	}
+
+	return strings.Join(conditions, " AND "), values
+}
+
+func getJSONFieldName(column string) string {
+	if name, ok := columnJSONNames[column]; ok {
+		return name
+	}
+
+	return column
+}
+
+func getFragmentSelectStatement(table string, connectionName string) string {
+	return getSelectStatement(table, getFragmentColumns(table, connectionName), getTableSuffix(connectionName))
+}
+
+func getSelectStatement(table string, columns []string, tableSuffix string) string {
+	return fmt.Sprintf(`SELECT %s FROM "%s%s"`, strings.Join(quoteColumns(columns), ", "), table, tableSuffix)
+}
+
+func quoteColumns(columns []string) []string {
+	quotedColumns := make([]string, len(columns))
+	for i, column := range columns {
+		quotedColumns[i] = fmt.Sprintf(`"%s"`, column)
+	}
+
+	return quotedColumns
+}
+
+func scanFragmentRow(rows *sql.Rows, columns []string, fields map[string]interface{}) error {
+	destinations := make([]interface{}, len(columns))
+	for i, column := range columns {
+		destination, ok := fields[column]
+		if !ok {
+			return fmt.Errorf("no field mapped for column %s", column)
+		}
+		destinations[i] = destination
+	}
+
+	return rows.Scan(destinations...)
+}
+
+func getPartenerFields(partener *repositories.Partener) map[string]interface{} {
+	return map[string]interface{}{
+		"CodPartener":  &partener.CodPartener,
+		"NumePartener": &partener.NumePartener,
+		"CUI":          &partener.CUI,
+		"EMail":        &partener.Email,
+		"IdAdresa":     &partener.IDAdresa,
+	}
+}
+
+func getAdresaFields(adresa *repositories.Adresa) map[string]interface{} {
+	return map[string]interface{}{
+		"IdAdresa":   &adresa.IDAdresa,
+		"NumeAdresa": &adresa.NumeAdresa,
+		"Oras":       &adresa.Oras,
+		"Judet":      &adresa.Judet,
+		"Sector":     &adresa.Sector,
+		"Strada":     &adresa.Strada,
+		"Numar":      &adresa.Numar,
+		"Bloc":       &adresa.Bloc,
+		"Etaj":       &adresa.Etaj,
+	}
+}
+
+func getVanzatorFields(vanzator *repositories.Vanzator) map[string]interface{} {
+	return map[string]interface{}{
+		"CodVanzator": &vanzator.CodVanzator,
+		"Nume":        &vanzator.Nume,
+		"Prenume":     &vanzator.Prenume,
+		"SalariuBaza": &vanzator.SalariuBaza,
+		"Comision":    &vanzator.Comision,
+		"EMail":       &vanzator.Email,
+		"IdAdresa":    &vanzator.IDAdresa,
+		"Activ":       &vanzator.Activ,
+	}
+}
+
+func getArticolFields(articol *repositories.Articol) map[string]interface{} {
+	return map[string]interface{}{
+		"CodArticol":        &articol.CodArticol,
+		"NumeArticol":       &articol.NumeArticol,
+		"CodGrupa":          &articol.CodGrupa,
+		"CantitateStoc":     &articol.CantitateStoc,
+		"IdUnitateDeMasura": &articol.IDUnitateMasura,
+	}
+}
+
+func getSucursalaFields(sucursala *repositories.Sucursala) map[string]interface{} {
+	return map[string]interface{}{
+		"IdSucursala":   &sucursala.IDSucursala,
+		"NumeSucursala": &sucursala.NumeSucursala,
+		"IdAdresa":      &sucursala.IDAdresa,
+	}
+}
+
+func getProiectFields(proiect *repositories.Proiect) map[string]interface{} {
+	return map[string]interface{}{
+		"IdProiect":   &proiect.IDProiect,
+		"NumeProiect": &proiect.NumeProiect,
+		"ValidDeLa":   &proiect.ValidDeLa,
+		"ValidPanaLa": &proiect.ValidPanaLa,
+		"Activ":       &proiect.Activ,
+	}
+}
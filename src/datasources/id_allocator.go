@@ -0,0 +1,162 @@
+package datasources
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	adreseSequence      = "Adrese"
+	vanzariSequence     = "Vanzari"
+	sucursaleSequence   = "Sucursale"
+	vanzatoriSequence   = "Vanzatori"
+	miscariStocSequence = "MiscariStoc"
+
+	idBlockSize = 20
+)
+
+type (
+	// A globalOnly sequence numbers a table that only exists on global.
+	sequence struct {
+		table      string
+		column     string
+		globalOnly bool
+	}
+
+	idBlock struct {
+		next  int
+		limit int
+	}
+)
+
+var sequences = map[string]sequence{
+	adreseSequence:      {table: "Adrese", column: "IdAdresa"},
+	vanzariSequence:     {table: "Vanzari", column: "IdIntrare"},
+	sucursaleSequence:   {table: "Sucursale", column: "IdSucursala"},
+	vanzatoriSequence:   {table: "Vanzatori", column: "CodVanzator"},
+	miscariStocSequence: {table: "MiscariStoc", column: "IdMiscare", globalOnly: true},
+}
+
+// IDs are handed out from blocks reserved on the "Secvente" table of the
+// global database, so every process and every site draws from the same
+// counter. IDs of a block that is not used up before a restart are skipped.
+var (
+	idAllocatorMutex sync.Mutex
+	idAllocator      *DBClient
+	idBlocks         = make(map[string]*idBlock)
+)
+
+// InitIDAllocator seeds every sequence missing from "Secvente" with the
+// highest ID found on any site and makes global the source of new IDs.
+func InitIDAllocator(connections Connections) error {
+	global, err := getSQLClient(connections[GlobalConnectionName])
+	if err != nil {
+		return err
+	}
+
+	for name, seq := range sequences {
+		seeded, err := isSequenceSeeded(global, name)
+		if err != nil {
+			return err
+		}
+		if seeded {
+			continue
+		}
+
+		lastID := 0
+		for _, connectionName := range connectionNames {
+			if seq.globalOnly && connectionName != GlobalConnectionName {
+				continue
+			}
+
+			client, err := getSQLClient(connections[connectionName])
+			if err != nil {
+				return err
+			}
+
+			maxID, err := queryInt(client.db, fmt.Sprintf(`SELECT NVL(MAX("%s"), 0) FROM "%s%s"`, seq.column, seq.table, client.tableSuffix))
+			if err != nil {
+				return fmt.Errorf("could not read the last %s on %s: %w", seq.column, connectionName, err)
+			}
+			if maxID > lastID {
+				lastID = maxID
+			}
+		}
+
+		_, err = global.db.Exec(`INSERT INTO "Secvente"("NumeSecventa", "UltimaValoare") VALUES(:1, :2)`, name, lastID)
+		if err != nil {
+			// Another process may have seeded it in the meantime.
+			seeded, checkErr := isSequenceSeeded(global, name)
+			if checkErr != nil || !seeded {
+				return fmt.Errorf("could not seed sequence %s: %w", name, err)
+			}
+		}
+	}
+
+	idAllocatorMutex.Lock()
+	defer idAllocatorMutex.Unlock()
+	idAllocator = &global
+	idBlocks = make(map[string]*idBlock)
+
+	return nil
+}
+
+// nextID must not be called while holding a transaction on the global
+// database: the SQLite sites share it and the reservation would wait on it.
+func nextID(name string) (int, error) {
+	idAllocatorMutex.Lock()
+	defer idAllocatorMutex.Unlock()
+
+	if idAllocator == nil {
+		return 0, errors.New("id allocator is not initialized")
+	}
+
+	block, ok := idBlocks[name]
+	if !ok || block.next > block.limit {
+		var err error
+		block, err = reserveIDBlock(*idAllocator, name)
+		if err != nil {
+			return 0, fmt.Errorf("could not reserve ids for %s: %w", name, err)
+		}
+		idBlocks[name] = block
+	}
+
+	ID := block.next
+	block.next++
+
+	return ID, nil
+}
+
+func reserveIDBlock(global DBClient, name string) (*idBlock, error) {
+	var limit int
+
+	err := global.runTransaction(func(q execer) error {
+		result, err := q.Exec(`UPDATE "Secvente" SET "UltimaValoare" = "UltimaValoare" + :1 WHERE "NumeSecventa" = :2`, idBlockSize, name)
+		if err != nil {
+			return err
+		}
+		updatedRows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if updatedRows == 0 {
+			return fmt.Errorf("sequence %s is not seeded", name)
+		}
+
+		limit, err = queryInt(q, `SELECT "UltimaValoare" FROM "Secvente" WHERE "NumeSecventa" = :1`, name)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &idBlock{next: limit - idBlockSize + 1, limit: limit}, nil
+}
+
+func isSequenceSeeded(global DBClient, name string) (bool, error) {
+	count, err := queryInt(global.db, `SELECT COUNT(*) FROM "Secvente" WHERE "NumeSecventa" = :1`, name)
+
+	return count > 0, err
+}
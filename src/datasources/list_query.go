@@ -0,0 +1,306 @@
+package datasources
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"modbSalesApp/src/repositories"
+)
+
+const (
+	listPageSize    = 100
+	maxListPageSize = 1000
+)
+
+type (
+	// listTable describes a table the list query can read: the typed fields
+	// its columns scan into, as used for reconstruction, and the columns
+	// holding dates, which are filtered as MM/DD/YYYY.
+	listTable struct {
+		fields      func() map[string]interface{}
+		dateColumns []string
+	}
+
+	listQuery struct {
+		columns []string
+		filter  sqlFilter
+		orderBy string
+		offset  int
+		limit   int
+		cerere  string
+	}
+
+	// listCursor is the position of a page in the result of a list query.
+	// Cerere identifies the table, site, filters and order it was made for.
+	listCursor struct {
+		Pozitie int    `json:"p"`
+		Cerere  string `json:"c"`
+	}
+)
+
+var listTables = map[string]listTable{
+	"Articole": {
+		fields: func() map[string]interface{} { return getArticolFields(&repositories.Articol{}) },
+	},
+	"Parteneri": {
+		fields: func() map[string]interface{} { return getPartenerFields(&repositories.Partener{}) },
+	},
+	"Adrese": {
+		fields: func() map[string]interface{} { return getAdresaFields(&repositories.Adresa{}) },
+	},
+	"Vanzatori": {
+		fields: func() map[string]interface{} { return getVanzatorFields(&repositories.Vanzator{}) },
+	},
+	"Sucursale": {
+		fields: func() map[string]interface{} { return getSucursalaFields(&repositories.Sucursala{}) },
+	},
+	"Proiecte": {
+		fields:      func() map[string]interface{} { return getProiectFields(&repositories.Proiect{}) },
+		dateColumns: []string{"ValidDeLa", "ValidPanaLa"},
+	},
+}
+
+var listOperators = map[string]string{
+	"eq":   "=",
+	"ne":   "<>",
+	"lt":   "<",
+	"lte":  "<=",
+	"gt":   ">",
+	"gte":  ">=",
+	"like": "LIKE",
+}
+
+// GetLista reads a page of table on this site. Filters, sort and fields name
+// the JSON fields of the table; the site must hold every one of them. Rows are
+// ordered by the sort fields and then by the table key, and only the asked
+// fields, or all the fields of the site, are returned. Cursors hold the
+// position of the page, as reference tables change seldom.
+func (client DBClient) GetLista(table string, params repositories.ListParams) (repositories.PaginaLista, error) {
+	query, err := client.getListQuery(table, params)
+	if err != nil {
+		return repositories.PaginaLista{}, err
+	}
+
+	total, err := queryInt(
+		client.db,
+		bindPlaceholders(fmt.Sprintf(`SELECT COUNT(*) FROM "%s%s" %s`, table, client.tableSuffix, query.filter.whereStatement())),
+		query.filter.args...,
+	)
+	if err != nil {
+		return repositories.PaginaLista{}, err
+	}
+
+	rows, err := client.db.Query(
+		bindPlaceholders(fmt.Sprintf(
+			`%s %s ORDER BY %s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY`,
+			getSelectStatement(table, query.columns, client.tableSuffix), query.filter.whereStatement(), query.orderBy, query.offset, query.limit+1,
+		)),
+		query.filter.args...,
+	)
+	if err != nil {
+		return repositories.PaginaLista{}, err
+	}
+
+	pagina := repositories.PaginaLista{Rezultate: []map[string]interface{}{}, NumarTotal: total}
+
+	defer rows.Close()
+	for rows.Next() {
+		fields := listTables[table].fields()
+		err := scanFragmentRow(rows, query.columns, fields)
+		if err != nil {
+			return repositories.PaginaLista{}, err
+		}
+
+		row := make(map[string]interface{}, len(query.columns))
+		for _, column := range query.columns {
+			row[getJSONFieldName(column)] = reflect.ValueOf(fields[column]).Elem().Interface()
+		}
+		pagina.Rezultate = append(pagina.Rezultate, row)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return repositories.PaginaLista{}, err
+	}
+
+	if len(pagina.Rezultate) > query.limit {
+		pagina.Rezultate = pagina.Rezultate[:query.limit]
+		pagina.CursorUrmator = encodeListCursor(listCursor{Pozitie: query.offset + query.limit, Cerere: query.cerere})
+	}
+	if query.offset > 0 {
+		pozitie := query.offset - query.limit
+		if pozitie < 0 {
+			pozitie = 0
+		}
+		pagina.CursorAnterior = encodeListCursor(listCursor{Pozitie: pozitie, Cerere: query.cerere})
+	}
+
+	return pagina, nil
+}
+
+func (client DBClient) getListQuery(table string, params repositories.ListParams) (listQuery, error) {
+	definition, ok := listTables[table]
+	if !ok {
+		return listQuery{}, fmt.Errorf("%s cannot be listed", table)
+	}
+
+	query := listQuery{limit: params.Limita, cerere: getListCerere(table, client.name, params)}
+	if query.limit == 0 {
+		query.limit = listPageSize
+	}
+	if query.limit < 0 || query.limit > maxListPageSize {
+		return listQuery{}, fmt.Errorf("limit must be between 1 and %d: %w", maxListPageSize, ErrInvalidQuery)
+	}
+
+	for _, camp := range params.Campuri {
+		column, err := getListColumn(table, client.name, camp)
+		if err != nil {
+			return listQuery{}, err
+		}
+		if !containsString(query.columns, column) {
+			query.columns = append(query.columns, column)
+		}
+	}
+	if len(query.columns) == 0 {
+		query.columns = getFragmentColumns(table, client.name)
+	}
+
+	fields := definition.fields()
+	for _, filtru := range params.Filtre {
+		column, err := getListColumn(table, client.name, filtru.Camp)
+		if err != nil {
+			return listQuery{}, err
+		}
+
+		condition, value, err := getListCondition(column, filtru, fields[column], containsString(definition.dateColumns, column))
+		if err != nil {
+			return listQuery{}, err
+		}
+		query.filter.where(condition, value)
+	}
+
+	var (
+		orderBy       []string
+		sortedColumns []string
+	)
+	for _, sortare := range params.Sortare {
+		column, err := getListColumn(table, client.name, sortare.Camp)
+		if err != nil {
+			return listQuery{}, err
+		}
+
+		direction := "ASC"
+		if sortare.Descrescator {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, fmt.Sprintf(`"%s" %s`, column, direction))
+		sortedColumns = append(sortedColumns, column)
+	}
+	for _, column := range fragmentationCatalog[table].key {
+		if !containsString(sortedColumns, column) {
+			orderBy = append(orderBy, fmt.Sprintf(`"%s" ASC`, column))
+		}
+	}
+	query.orderBy = strings.Join(orderBy, ", ")
+
+	if len(params.Cursor) > 0 {
+		cursor, err := decodeListCursor(params.Cursor)
+		if err != nil {
+			return listQuery{}, err
+		}
+		if cursor.Cerere != query.cerere {
+			return listQuery{}, fmt.Errorf("cursor was made for other filters or another sort, send the same ones with it: %w", ErrInvalidQuery)
+		}
+		query.offset = cursor.Pozitie
+	}
+
+	return query, nil
+}
+
+// getListColumn returns the column of table behind the JSON field name,
+// which must be held on site.
+func getListColumn(table string, site string, camp string) (string, error) {
+	for _, column := range fragmentationCatalog[table].columns {
+		if getJSONFieldName(column) != camp {
+			continue
+		}
+		if !containsString(getFragmentColumns(table, site), column) {
+			return "", fmt.Errorf("field %s of %s is not held on %s: %w", camp, table, site, ErrInvalidQuery)
+		}
+
+		return column, nil
+	}
+
+	return "", fmt.Errorf("%s has no field %s: %w", table, camp, ErrInvalidQuery)
+}
+
+// getListCondition compares column with the value of filtru converted to the
+// type of field, the column's field in its repositories struct.
+func getListCondition(column string, filtru repositories.FiltruLista, field interface{}, isDate bool) (string, interface{}, error) {
+	operator, ok := listOperators[filtru.Operator]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown operator '%s' in the filter on %s, use eq, ne, lt, lte, gt, gte or like: %w", filtru.Operator, filtru.Camp, ErrInvalidQuery)
+	}
+
+	var (
+		value      interface{}
+		err        error
+		expression = "?"
+		isText     bool
+	)
+	switch field.(type) {
+	case *int:
+		value, err = strconv.Atoi(filtru.Valoare)
+	case *float32:
+		value, err = strconv.ParseFloat(filtru.Valoare, 64)
+	case *repositories.Money:
+		value, err = repositories.ParseMoney(filtru.Valoare)
+	default:
+		value = filtru.Valoare
+		isText = !isDate
+		if isDate {
+			_, err = time.Parse("01/02/2006", filtru.Valoare)
+			expression = `TO_DATE(?, 'MM/DD/YYYY')`
+		}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("'%s' is not a valid value for %s: %w", filtru.Valoare, filtru.Camp, ErrInvalidQuery)
+	}
+	if operator == "LIKE" && !isText {
+		return "", nil, fmt.Errorf("like only filters text fields, %s is not one: %w", filtru.Camp, ErrInvalidQuery)
+	}
+
+	return fmt.Sprintf(`"%s" %s %s`, column, operator, expression), value, nil
+}
+
+func getListCerere(table string, site string, params repositories.ListParams) string {
+	hash := fnv.New64a()
+	_, _ = fmt.Fprintf(hash, "%s|%s|%v|%v", table, site, params.Filtre, params.Sortare)
+
+	return strconv.FormatUint(hash.Sum64(), 36)
+}
+
+func encodeListCursor(cursor listCursor) string {
+	content, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func decodeListCursor(value string) (listCursor, error) {
+	var cursor listCursor
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(content, &cursor)
+	}
+	if err != nil || cursor.Pozitie < 0 {
+		return listCursor{}, fmt.Errorf("cursor '%s' is not valid: %w", value, ErrInvalidQuery)
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,62 @@
+package datasources
+
+import "modbSalesApp/src/repositories"
+
+// Parteneri and Adrese are fragmented vertically: global holds every column
+// and each local site the columns of its fragment, so a partner and its
+// address are written on all of them in one distributed transaction.
+
+func (client DBClient) InsertPartener(partenerAdresa repositories.InsertPartener, connections Connections) error {
+	IDAdresa, err := nextID(adreseSequence)
+	if err != nil {
+		return err
+	}
+
+	partener := partenerAdresa.Partener
+	partener.IDAdresa = IDAdresa
+	adresa := partenerAdresa.Adresa
+	adresa.IDAdresa = IDAdresa
+
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		err := forEachReplica(tx, connections, "Adrese", func(client DBClient, q execer) error {
+			return client.insertFragmentRow(q, "Adrese", getAdresaFields(&adresa))
+		})
+		if err != nil {
+			return err
+		}
+
+		return forEachReplica(tx, connections, "Parteneri", func(client DBClient, q execer) error {
+			return client.insertFragmentRow(q, "Parteneri", getPartenerFields(&partener))
+		})
+	})
+}
+
+// EditPartener updates the partner and the address it points to.
+func (client DBClient) EditPartener(partenerAdresa repositories.InsertPartener, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		global, q, err := tx.enlist(connections[GlobalConnectionName])
+		if err != nil {
+			return err
+		}
+
+		partener := partenerAdresa.Partener
+		IDAdresa, err := global.getIDAdresaPartener(q, partener.CodPartener)
+		if err != nil {
+			return err
+		}
+		partener.IDAdresa = IDAdresa
+		adresa := partenerAdresa.Adresa
+		adresa.IDAdresa = IDAdresa
+
+		err = forEachReplica(tx, connections, "Parteneri", func(client DBClient, q execer) error {
+			return client.updateFragmentRow(q, "Parteneri", getPartenerFields(&partener))
+		})
+		if err != nil {
+			return err
+		}
+
+		return forEachReplica(tx, connections, "Adrese", func(client DBClient, q execer) error {
+			return client.updateFragmentRow(q, "Adrese", getAdresaFields(&adresa))
+		})
+	})
+}
@@ -0,0 +1,123 @@
+package datasources
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"modbSalesApp/src/repositories"
+)
+
+// Rounding policy: amounts are kept to 2 decimals, rounded half away from
+// zero (1.005 becomes 1.01, -1.005 becomes -1.01). Discount and VAT of a line
+// are rounded first, then TotalLinie = round(Cantitate * Pret - Discount + VAT)
+// is rounded once, on the exact product. The Total, VAT and Discount of a sale
+// are the sums of the rounded line values and need no further rounding.
+//
+// Amounts are repositories.Money, so 24.54 is exactly 24.54; Cantitate is read
+// from its shortest decimal form.
+
+// PricingError reports a total sent by the client that differs from the one
+// computed from its parts. Linie is the 1-based line, 0 for the sale header
+// or a line not numbered yet.
+type PricingError struct {
+	Linie    int
+	Camp     string
+	Trimis   repositories.Money
+	Calculat repositories.Money
+}
+
+func (e PricingError) Error() string {
+	switch {
+	case e.Linie > 0:
+		return fmt.Sprintf("linia %d %s is %s, computed %s", e.Linie, e.Camp, e.Trimis, e.Calculat)
+	case e.Camp == "TotalLinie":
+		return fmt.Sprintf("%s is %s, computed %s", e.Camp, e.Trimis, e.Calculat)
+	default:
+		return fmt.Sprintf("vanzare %s is %s, computed %s", e.Camp, e.Trimis, e.Calculat)
+	}
+}
+
+func (e PricingError) Unwrap() error {
+	return ErrPriceMismatch
+}
+
+// PriceVanzare computes every line and the header of the sale. Totals left at
+// 0 by the client are filled in; totals that do not round to the computed
+// value are reported as a PricingError.
+func PriceVanzare(vanzareLinii *repositories.InsertVanzare) error {
+	var total, vat, discount repositories.Money
+
+	for i := range vanzareLinii.LiniiVanzari {
+		linie := &vanzareLinii.LiniiVanzari[i]
+		err := PriceLinieVanzare(linie, i+1)
+		if err != nil {
+			return err
+		}
+
+		total, err = total.Add(linie.TotalLinie)
+		if err != nil {
+			return err
+		}
+		vat, err = vat.Add(linie.VAT)
+		if err != nil {
+			return err
+		}
+		discount, err = discount.Add(linie.Discount)
+		if err != nil {
+			return err
+		}
+	}
+
+	vanzare := &vanzareLinii.Vanzare
+	for _, amount := range []struct {
+		camp     string
+		value    *repositories.Money
+		computed repositories.Money
+	}{
+		{"Total", &vanzare.Total, total},
+		{"VAT", &vanzare.VAT, vat},
+		{"Discount", &vanzare.Discount, discount},
+	} {
+		err := checkAmount(amount.value, amount.computed, 0, amount.camp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PriceLinieVanzare rounds Discount and VAT and computes TotalLinie; nrLinie
+// only names the line in a PricingError.
+func PriceLinieVanzare(linie *repositories.LinieVanzare, nrLinie int) error {
+	linie.Discount = linie.Discount.Round(2)
+	linie.VAT = linie.VAT.Round(2)
+
+	total := new(big.Rat).Mul(getRat(linie.Cantitate), linie.Pret.Rat())
+	total.Sub(total, linie.Discount.Rat())
+	total.Add(total, linie.VAT.Rat())
+
+	totalLinie, err := repositories.RoundMoney(total, 2)
+	if err != nil {
+		return err
+	}
+
+	return checkAmount(&linie.TotalLinie, totalLinie, nrLinie, "TotalLinie")
+}
+
+func checkAmount(value *repositories.Money, computed repositories.Money, nrLinie int, camp string) error {
+	if !value.IsZero() && value.Round(2) != computed {
+		return PricingError{Linie: nrLinie, Camp: camp, Trimis: *value, Calculat: computed}
+	}
+	*value = computed
+
+	return nil
+}
+
+// getRat reads value from its shortest decimal form.
+func getRat(value float32) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(float64(value), 'f', -1, 32))
+
+	return r
+}
@@ -0,0 +1,77 @@
+package datasources
+
+import (
+	"fmt"
+	"time"
+
+	"modbSalesApp/src/repositories"
+)
+
+// ProiectActiv marks, in "Activ", a project sale lines can be attributed to.
+const ProiectActiv = "Y"
+
+// checkProiectValid checks that dataVanzare falls between validDeLa and
+// validPanaLa, both included; a missing bound leaves that side open. Dates
+// are MM/DD/YYYY.
+func checkProiectValid(IDProiect string, validDeLa string, validPanaLa string, dataVanzare string) error {
+	data, err := time.Parse("01/02/2006", dataVanzare)
+	if err != nil {
+		return fmt.Errorf("could not read the date of the sale: %w", err)
+	}
+
+	if len(validDeLa) > 0 {
+		deLa, err := time.Parse("01/02/2006", validDeLa)
+		if err != nil {
+			return err
+		}
+		if data.Before(deLa) {
+			return fmt.Errorf("proiect %s is valid from %s, the sale is from %s: %w", IDProiect, validDeLa, dataVanzare, ErrInvalidProiect)
+		}
+	}
+	if len(validPanaLa) > 0 {
+		panaLa, err := time.Parse("01/02/2006", validPanaLa)
+		if err != nil {
+			return err
+		}
+		if data.After(panaLa) {
+			return fmt.Errorf("proiect %s is valid until %s, the sale is from %s: %w", IDProiect, validPanaLa, dataVanzare, ErrInvalidProiect)
+		}
+	}
+
+	return nil
+}
+
+// GetVanzariProiect gathers the lines attributed to the project from every
+// site holding sales, cancelled sales excepted, with their revenue and
+// quantity.
+func GetVanzariProiect(connections Connections, IDProiect string) (repositories.VanzariProiect, error) {
+	_, err := connections[GlobalConnectionName].GetProiect(IDProiect)
+	if err != nil {
+		return repositories.VanzariProiect{}, err
+	}
+
+	vanzari := repositories.VanzariProiect{IDProiect: IDProiect, Linii: []repositories.LinieVanzare{}}
+	for _, site := range getRoutedSites() {
+		client, ok := connections[site]
+		if !ok {
+			return repositories.VanzariProiect{}, fmt.Errorf("no connection configured for site %s", site)
+		}
+
+		linii, err := client.GetLiniiProiect(IDProiect)
+		if err != nil {
+			return repositories.VanzariProiect{}, fmt.Errorf("could not get linii from %s: %w", site, err)
+		}
+
+		for _, linie := range linii {
+			vanzari.Venit, err = vanzari.Venit.Add(linie.TotalLinie)
+			if err != nil {
+				return repositories.VanzariProiect{}, err
+			}
+			vanzari.Cantitate += float64(linie.Cantitate)
+		}
+		vanzari.Linii = append(vanzari.Linii, linii...)
+	}
+	vanzari.NumarLinii = len(vanzari.Linii)
+
+	return vanzari, nil
+}
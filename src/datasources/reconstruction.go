@@ -0,0 +1,161 @@
+package datasources
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"modbSalesApp/src/repositories"
+)
+
+type reconstructedRow struct {
+	values  map[string]interface{}
+	sources map[string]string
+}
+
+func ReconstructParteneri(connections Connections) ([]repositories.ReconstructedPartener, error) {
+	rows, err := reconstructFragments("Parteneri", connections, func(client Store) ([]map[string]interface{}, error) {
+		parteneri, err := client.GetParteneri()
+		fields := make([]map[string]interface{}, len(parteneri))
+		for i := range parteneri {
+			fields[i] = getPartenerFields(&parteneri[i])
+		}
+
+		return fields, err
+	})
+	if err != nil {
+		return []repositories.ReconstructedPartener{}, err
+	}
+
+	parteneri := make([]repositories.ReconstructedPartener, len(rows))
+	for i, row := range rows {
+		setFieldValues(getPartenerFields(&parteneri[i].Partener), row.values)
+		parteneri[i].Surse = row.sources
+	}
+
+	return parteneri, nil
+}
+
+func ReconstructAdrese(connections Connections) ([]repositories.ReconstructedAdresa, error) {
+	rows, err := reconstructFragments("Adrese", connections, func(client Store) ([]map[string]interface{}, error) {
+		adrese, err := client.GetAdrese()
+		fields := make([]map[string]interface{}, len(adrese))
+		for i := range adrese {
+			fields[i] = getAdresaFields(&adrese[i])
+		}
+
+		return fields, err
+	})
+	if err != nil {
+		return []repositories.ReconstructedAdresa{}, err
+	}
+
+	adrese := make([]repositories.ReconstructedAdresa, len(rows))
+	for i, row := range rows {
+		setFieldValues(getAdresaFields(&adrese[i].Adresa), row.values)
+		adrese[i].Surse = row.sources
+	}
+
+	return adrese, nil
+}
+
+func ReconstructVanzatori(connections Connections) ([]repositories.ReconstructedVanzator, error) {
+	rows, err := reconstructFragments("Vanzatori", connections, func(client Store) ([]map[string]interface{}, error) {
+		vanzatori, err := client.GetVanzatori()
+		fields := make([]map[string]interface{}, len(vanzatori))
+		for i := range vanzatori {
+			fields[i] = getVanzatorFields(&vanzatori[i])
+		}
+
+		return fields, err
+	})
+	if err != nil {
+		return []repositories.ReconstructedVanzator{}, err
+	}
+
+	vanzatori := make([]repositories.ReconstructedVanzator, len(rows))
+	for i, row := range rows {
+		setFieldValues(getVanzatorFields(&vanzatori[i].Vanzator), row.values)
+		vanzatori[i].Surse = row.sources
+	}
+
+	return vanzatori, nil
+}
+
+// reconstructFragments reads the vertical fragments of table from every site
+// holding one, concurrently, and joins the partial rows on the table key. When
+// several sites hold the same column, the first site in connectionNames wins.
+func reconstructFragments(table string, connections Connections, readFragment func(client Store) ([]map[string]interface{}, error)) ([]reconstructedRow, error) {
+	sites := getVerticalFragmentSites(table)
+	results := make([][]map[string]interface{}, len(sites))
+	errs := make([]error, len(sites))
+
+	for _, site := range sites {
+		if _, ok := connections[site]; !ok {
+			return nil, fmt.Errorf("no connection configured for site %s", site)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, site := range sites {
+		wg.Add(1)
+		go func(i int, client Store) {
+			defer wg.Done()
+			results[i], errs[i] = readFragment(client)
+		}(i, connections[site])
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s fragment from %s: %w", table, sites[i], err)
+		}
+	}
+
+	var rows []reconstructedRow
+	rowIndexes := make(map[string]int)
+	key := fragmentationCatalog[table].key
+	for i, site := range sites {
+		columns := getFragmentColumns(table, site)
+		for _, fields := range results[i] {
+			keyValue := getKeyValue(fields, key)
+			index, ok := rowIndexes[keyValue]
+			if !ok {
+				index = len(rows)
+				rowIndexes[keyValue] = index
+				rows = append(rows, reconstructedRow{
+					values:  make(map[string]interface{}),
+					sources: make(map[string]string),
+				})
+			}
+
+			for _, column := range columns {
+				if _, isSet := rows[index].values[column]; isSet {
+					continue
+				}
+				rows[index].values[column] = reflect.ValueOf(fields[column]).Elem().Interface()
+				rows[index].sources[getJSONFieldName(column)] = site
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+func getKeyValue(fields map[string]interface{}, key []string) string {
+	values := make([]string, len(key))
+	for i, column := range key {
+		values[i] = fmt.Sprint(reflect.ValueOf(fields[column]).Elem().Interface())
+	}
+
+	return strings.Join(values, "|")
+}
+
+func setFieldValues(fields map[string]interface{}, values map[string]interface{}) {
+	for column, value := range values {
+		if field, ok := fields[column]; ok {
+			reflect.ValueOf(field).Elem().Set(reflect.ValueOf(value))
+		}
+	}
+}
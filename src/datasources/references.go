@@ -0,0 +1,103 @@
+package datasources
+
+import "fmt"
+
+// DeletePartener deletes the partner from global and its fragments once no
+// site holds a sale referencing it; its address goes too unless something else
+// uses it.
+// DeletePartener locks the partner on global before counting its sales on
+// every site, so that a sale saved meanwhile, which takes the same lock, is
+// either counted or finds the partner gone.
+func (client DBClient) DeletePartener(codPartener string, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		global, q, err := tx.enlist(connections[GlobalConnectionName])
+		if err != nil {
+			return err
+		}
+
+		err = global.lockPartener(q, codPartener)
+		if err != nil {
+			return err
+		}
+
+		IDAdresa, err := global.getIDAdresaPartener(q, codPartener)
+		if err != nil {
+			return err
+		}
+
+		vanzari, err := countInTransaction(tx, connections, func(client DBClient, q execer) (int, error) {
+			return client.countVanzariPartener(q, codPartener)
+		})
+		if err != nil {
+			return err
+		}
+		if vanzari > 0 {
+			return fmt.Errorf("partener %s is used by %d vanzari: %w", codPartener, vanzari, ErrReferenced)
+		}
+
+		partener := map[string]interface{}{"CodPartener": codPartener}
+		err = forEachReplica(tx, connections, "Parteneri", func(client DBClient, q execer) error {
+			return client.deleteFragmentRow(q, "Parteneri", partener)
+		})
+		if err != nil {
+			return err
+		}
+
+		deleted, err := global.deleteUnusedAdresa(q, IDAdresa)
+		if err != nil || !deleted {
+			return err
+		}
+
+		adresa := map[string]interface{}{"IdAdresa": IDAdresa}
+		return forEachReplica(tx, connections, "Adrese", func(client DBClient, q execer) error {
+			return client.deleteFragmentRow(q, "Adrese", adresa)
+		})
+	})
+}
+
+// countInTransaction adds up count on global and every local site, each read
+// through the participant of tx enlisted for its database.
+func countInTransaction(tx *distributedTransaction, connections Connections, count func(client DBClient, q execer) (int, error)) (int, error) {
+	total := 0
+	for _, connectionName := range connectionNames {
+		store, ok := connections[connectionName]
+		if !ok {
+			return 0, fmt.Errorf("no connection configured for site %s", connectionName)
+		}
+
+		client, q, err := tx.enlist(store)
+		if err != nil {
+			return 0, err
+		}
+
+		siteCount, err := count(client, q)
+		if err != nil {
+			return 0, fmt.Errorf("could not count references on %s: %w", connectionName, err)
+		}
+		total += siteCount
+	}
+
+	return total, nil
+}
+
+func countOnLocalSites(connections Connections, count func(client Store) (int, error)) (int, error) {
+	total := 0
+	for _, connectionName := range connectionNames {
+		if connectionName == GlobalConnectionName {
+			continue
+		}
+
+		client, ok := connections[connectionName]
+		if !ok {
+			return 0, fmt.Errorf("no connection configured for site %s", connectionName)
+		}
+
+		siteCount, err := count(client)
+		if err != nil {
+			return 0, fmt.Errorf("could not count references on %s: %w", connectionName, err)
+		}
+		total += siteCount
+	}
+
+	return total, nil
+}
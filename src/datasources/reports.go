@@ -0,0 +1,625 @@
+package datasources
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"modbSalesApp/src/repositories"
+)
+
+type (
+	// reportTable is a table reports can join to a sale: the table it hangs
+	// from and the join condition. Vanzari, as v, is the root of every query.
+	reportTable struct {
+		alias     string
+		table     string
+		parent    string
+		condition string
+	}
+
+	// reportDimension is a column of a report. The expression is grouped by on
+	// every site; when lookup is set, it yields keys that lookup translates,
+	// from global, into the values of the dimension.
+	reportDimension struct {
+		name       string
+		column     string
+		expression string
+		table      string
+		isInt      bool
+		lookup     func(global Store) (map[string]string, error)
+	}
+
+	reportMeasure struct {
+		name   string
+		column string
+	}
+
+	// AgregatRaport holds the measures of one group of a report on one site.
+	// The discount percentage is kept as a sum and a count so that groups from
+	// several sites, or several keys of a looked up dimension, can be merged.
+	AgregatRaport struct {
+		Dimensiuni            []sql.NullString
+		Platit                repositories.Money
+		Cantitate             float64
+		SumaProcenteDiscount  float64
+		NumarProcenteDiscount int
+		NumarVanzari          int
+	}
+
+	reportGroup struct {
+		valori  []interface{}
+		agregat AgregatRaport
+	}
+)
+
+// reportTables are in join order, each after the table it hangs from. They are
+// replicated on every site, so sales can be joined to them locally.
+var reportTables = []reportTable{
+	{alias: "lv", table: "LiniiVanzari", parent: "v", condition: `lv."IdIntrare" = v."IdIntrare"`},
+	{alias: "a", table: "Articole", parent: "lv", condition: `lv."CodArticol" = a."CodArticol"`},
+	{alias: "ga", table: "GrupaArticole", parent: "a", condition: `a."CodGrupa" = ga."CodGrupa"`},
+}
+
+var reportDimensions = []reportDimension{
+	{name: "grupa", column: "Grupa", expression: `ga."NumeGrupa"`, table: "ga"},
+	{name: "judet", column: "Judet", expression: `v."IdSucursala"`, lookup: getJudeteSucursale},
+	{name: "sucursala", column: "IDSucursala", expression: `v."IdSucursala"`, isInt: true},
+	{name: "vanzator", column: "CodVanzator", expression: `v."CodVanzator"`, isInt: true},
+	{name: "partener", column: "CodPartener", expression: `v."CodPartener"`},
+	{name: "articol", column: "CodArticol", expression: `lv."CodArticol"`, table: "lv"},
+	{name: "proiect", column: "IDProiect", expression: `lv."IdProiect"`, table: "lv"},
+	{name: "day", column: "Zi", expression: `TO_CHAR(v."Data", 'YYYY-MM-DD')`},
+	{name: "week", column: "Saptamana", expression: `TO_CHAR(v."Data", 'IYYY') || '-W' || TO_CHAR(v."Data", 'IW')`},
+	{name: "month", column: "Luna", expression: `TO_CHAR(v."Data", 'YYYY-MM')`},
+	{name: "quarter", column: "Trimestru", expression: `TO_CHAR(v."Data", 'YYYY') || '-Q' || TO_CHAR(v."Data", 'Q')`},
+	{name: "year", column: "An", expression: `TO_CHAR(v."Data", 'YYYY')`},
+}
+
+const (
+	MasuraPlatit          = "platit"
+	MasuraCantitate       = "cantitate"
+	MasuraProcentDiscount = "discount"
+	MasuraNumarVanzari    = "vanzari"
+)
+
+var reportMeasures = []reportMeasure{
+	{name: MasuraPlatit, column: "Platit"},
+	{name: MasuraCantitate, column: "Cantitate"},
+	{name: MasuraProcentDiscount, column: "ProcentDiscount"},
+	{name: MasuraNumarVanzari, column: "NumarVanzari"},
+}
+
+// GetRaport groups the sales of every local site, cancelled sales excepted and
+// filtered on the fields of the report form, by the asked dimensions and
+// returns the asked measures of each group as a table: the dimension columns
+// in the asked order, then the measure columns. Sale measures of a group count
+// every sale with a line in it once, so with line dimensions, such as grupa, a
+// sale can count in several groups.
+func GetRaport(connections Connections, params repositories.RaportParams) (repositories.Raport, error) {
+	dimensiuni, masuri, err := getReportDefinition(params)
+	if err != nil {
+		return repositories.Raport{}, err
+	}
+
+	siteParams := repositories.RaportParams{Filtre: params.Filtre}
+	for _, dimensiune := range dimensiuni {
+		siteParams.Dimensiuni = append(siteParams.Dimensiuni, dimensiune.name)
+	}
+	for _, masura := range masuri {
+		siteParams.Masuri = append(siteParams.Masuri, masura.name)
+	}
+
+	coduriParteneri, err := getCoduriParteneri(connections[GlobalConnectionName], params.Filtre.NumePartener)
+	if err != nil {
+		return repositories.Raport{}, err
+	}
+
+	lookups := make([]map[string]string, len(dimensiuni))
+	for i, dimensiune := range dimensiuni {
+		if dimensiune.lookup == nil {
+			continue
+		}
+
+		lookups[i], err = dimensiune.lookup(connections[GlobalConnectionName])
+		if err != nil {
+			return repositories.Raport{}, fmt.Errorf("could not look up %s: %w", dimensiune.name, err)
+		}
+	}
+
+	groups := make(map[string]*reportGroup)
+	var keys []string
+	for _, site := range getRoutedSites() {
+		client, ok := connections[site]
+		if !ok {
+			return repositories.Raport{}, fmt.Errorf("no connection configured for site %s", site)
+		}
+
+		agregate, err := client.GetAgregateRaport(siteParams, coduriParteneri)
+		if err != nil {
+			return repositories.Raport{}, fmt.Errorf("could not get report from %s: %w", site, err)
+		}
+
+		for _, agregat := range agregate {
+			valori := getReportValues(dimensiuni, lookups, agregat.Dimensiuni)
+			key := fmt.Sprintf("%#v", valori)
+			group, ok := groups[key]
+			if !ok {
+				group = &reportGroup{valori: valori}
+				groups[key] = group
+				keys = append(keys, key)
+			}
+			err = group.agregat.add(agregat)
+			if err != nil {
+				return repositories.Raport{}, err
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return isReportRowBefore(groups[keys[i]].valori, groups[keys[j]].valori)
+	})
+
+	raport := repositories.Raport{Randuri: [][]interface{}{}}
+	for _, dimensiune := range dimensiuni {
+		raport.Coloane = append(raport.Coloane, dimensiune.column)
+	}
+	for _, masura := range masuri {
+		raport.Coloane = append(raport.Coloane, masura.column)
+	}
+	for _, key := range keys {
+		group := groups[key]
+		rand := append([]interface{}{}, group.valori...)
+		for _, masura := range masuri {
+			rand = append(rand, group.agregat.getMeasure(masura.name))
+		}
+		raport.Randuri = append(raport.Randuri, rand)
+	}
+
+	return raport, nil
+}
+
+// GetAgregateRaport groups the sales of this site by the named dimensions and
+// sums the named measures of each group. Sale measures are read from the
+// distinct sales of each group, quantities from the lines. When
+// coduriParteneri is not nil, only the sales of those partners are read.
+func (client DBClient) GetAgregateRaport(params repositories.RaportParams, coduriParteneri []string) ([]AgregatRaport, error) {
+	masuri := params.Masuri
+	definitions := make([]reportDimension, len(params.Dimensiuni))
+	for i, name := range params.Dimensiuni {
+		dimensiune, ok := getReportDimension(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown dimension '%s': %w", name, ErrInvalidQuery)
+		}
+		definitions[i] = dimensiune
+	}
+
+	groups := make(map[string]*AgregatRaport)
+	var keys []string
+	addAgregat := func(agregat AgregatRaport) error {
+		key := fmt.Sprintf("%#v", agregat.Dimensiuni)
+		group, ok := groups[key]
+		if !ok {
+			group = &AgregatRaport{Dimensiuni: agregat.Dimensiuni}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+
+		return group.add(agregat)
+	}
+
+	if containsString(masuri, MasuraPlatit) || containsString(masuri, MasuraProcentDiscount) || containsString(masuri, MasuraNumarVanzari) {
+		agregate, err := client.queryAgregateVanzari(definitions, client.getReportFilter(definitions, false, params.Filtre, coduriParteneri))
+		if err != nil {
+			return nil, err
+		}
+		for _, agregat := range agregate {
+			err = addAgregat(agregat)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if containsString(masuri, MasuraCantitate) {
+		agregate, err := client.queryAgregateLinii(definitions, client.getReportFilter(definitions, true, params.Filtre, coduriParteneri))
+		if err != nil {
+			return nil, err
+		}
+		for _, agregat := range agregate {
+			err = addAgregat(agregat)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	agregate := make([]AgregatRaport, len(keys))
+	for i, key := range keys {
+		agregate[i] = *groups[key]
+	}
+
+	return agregate, nil
+}
+
+func (client DBClient) queryAgregateVanzari(dimensiuni []reportDimension, filter sqlFilter) ([]AgregatRaport, error) {
+	selects := []string{`v."IdIntrare" "IdIntrare"`}
+	columns := make([]string, len(dimensiuni))
+	for i, dimensiune := range dimensiuni {
+		selects = append(selects, fmt.Sprintf(`%s "D%d"`, dimensiune.expression, i))
+		columns[i] = fmt.Sprintf(`x."D%d"`, i)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s NVL(SUM(v."Platit"), 0),
+			NVL(SUM(CASE WHEN NVL(v."Total", 0) = 0 THEN NULL ELSE v."Discount" * 100 / v."Total" END), 0),
+			COUNT(CASE WHEN NVL(v."Total", 0) = 0 THEN NULL ELSE 1 END),
+			COUNT(*)
+		FROM (SELECT DISTINCT %s %s %s) x, "Vanzari%s" v
+		WHERE v."IdIntrare" = x."IdIntrare"
+		%s
+	`, getReportSelect(columns), strings.Join(selects, ", "), filter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), filter.whereStatement(),
+		client.tableSuffix, getReportGroupBy(columns))
+
+	rows, err := client.db.Query(bindPlaceholders(query), filter.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var agregate []AgregatRaport
+
+	defer rows.Close()
+	for rows.Next() {
+		agregat := AgregatRaport{Dimensiuni: make([]sql.NullString, len(dimensiuni))}
+		destinations := make([]interface{}, 0, len(dimensiuni)+4)
+		for i := range agregat.Dimensiuni {
+			destinations = append(destinations, &agregat.Dimensiuni[i])
+		}
+		destinations = append(destinations, &agregat.Platit, &agregat.SumaProcenteDiscount, &agregat.NumarProcenteDiscount, &agregat.NumarVanzari)
+
+		err := rows.Scan(destinations...)
+		if err != nil {
+			return nil, err
+		}
+
+		agregate = append(agregate, agregat)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return agregate, nil
+}
+
+func (client DBClient) queryAgregateLinii(dimensiuni []reportDimension, filter sqlFilter) ([]AgregatRaport, error) {
+	columns := make([]string, len(dimensiuni))
+	for i, dimensiune := range dimensiuni {
+		columns[i] = dimensiune.expression
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s NVL(SUM(lv."Cantitate"), 0)
+		%s
+		%s
+		%s
+	`, getReportSelect(columns), filter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), filter.whereStatement(), getReportGroupBy(columns))
+
+	rows, err := client.db.Query(bindPlaceholders(query), filter.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var agregate []AgregatRaport
+
+	defer rows.Close()
+	for rows.Next() {
+		agregat := AgregatRaport{Dimensiuni: make([]sql.NullString, len(dimensiuni))}
+		destinations := make([]interface{}, 0, len(dimensiuni)+1)
+		for i := range agregat.Dimensiuni {
+			destinations = append(destinations, &agregat.Dimensiuni[i])
+		}
+		destinations = append(destinations, &agregat.Cantitate)
+
+		err := rows.Scan(destinations...)
+		if err != nil {
+			return nil, err
+		}
+
+		agregate = append(agregate, agregat)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return agregate, nil
+}
+
+// getReportFilter joins the tables the dimensions need, and the lines when
+// withLinii is set, applies the form filters and leaves cancelled sales out.
+func (client DBClient) getReportFilter(dimensiuni []reportDimension, withLinii bool, filtre repositories.FormParams, coduriParteneri []string) sqlFilter {
+	needed := make(map[string]bool)
+	if withLinii {
+		needed["lv"] = true
+	}
+	for _, dimensiune := range dimensiuni {
+		if len(dimensiune.table) > 0 {
+			needed[dimensiune.table] = true
+		}
+	}
+	for i := len(reportTables) - 1; i >= 0; i-- {
+		if needed[reportTables[i].alias] {
+			needed[reportTables[i].parent] = true
+		}
+	}
+
+	filter := getFormParamsFilter(filtre, coduriParteneri, client.tableSuffix, needed["lv"])
+	for _, table := range reportTables {
+		if needed[table.alias] {
+			filter.join(fmt.Sprintf(`"%s%s" %s`, table.table, client.tableSuffix, table.alias), table.condition)
+		}
+	}
+	filter.where(`(v."Status" IS NULL OR v."Status" <> ?)`, VanzareAnulata)
+
+	return filter
+}
+
+func getReportSelect(columns []string) string {
+	if len(columns) == 0 {
+		return ""
+	}
+
+	return strings.Join(columns, ", ") + ","
+}
+
+func getReportGroupBy(columns []string) string {
+	if len(columns) == 0 {
+		return ""
+	}
+
+	return "GROUP BY " + strings.Join(columns, ", ")
+}
+
+func getReportDefinition(params repositories.RaportParams) ([]reportDimension, []reportMeasure, error) {
+	var (
+		dimensiuni []reportDimension
+		names      []string
+	)
+	for _, name := range params.Dimensiuni {
+		dimensiune, ok := getReportDimension(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown dimension '%s', use %s: %w", name, strings.Join(getReportDimensionNames(), ", "), ErrInvalidQuery)
+		}
+		if !containsString(names, name) {
+			dimensiuni = append(dimensiuni, dimensiune)
+			names = append(names, name)
+		}
+	}
+
+	var (
+		masuri       []reportMeasure
+		measureNames []string
+	)
+	for _, name := range params.Masuri {
+		masura, ok := getReportMeasure(name)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown measure '%s', use %s, %s, %s or %s: %w", name, MasuraPlatit, MasuraCantitate, MasuraProcentDiscount, MasuraNumarVanzari, ErrInvalidQuery)
+		}
+		if !containsString(measureNames, name) {
+			masuri = append(masuri, masura)
+			measureNames = append(measureNames, name)
+		}
+	}
+	if len(masuri) == 0 {
+		return nil, nil, fmt.Errorf("at least one measure is needed: %w", ErrInvalidQuery)
+	}
+
+	return dimensiuni, masuri, nil
+}
+
+func getReportDimension(name string) (reportDimension, bool) {
+	for _, dimensiune := range reportDimensions {
+		if dimensiune.name == name {
+			return dimensiune, true
+		}
+	}
+
+	return reportDimension{}, false
+}
+
+func getReportDimensionNames() []string {
+	names := make([]string, len(reportDimensions))
+	for i, dimensiune := range reportDimensions {
+		names[i] = dimensiune.name
+	}
+
+	return names
+}
+
+func getReportMeasure(name string) (reportMeasure, bool) {
+	for _, masura := range reportMeasures {
+		if masura.name == name {
+			return masura, true
+		}
+	}
+
+	return reportMeasure{}, false
+}
+
+// getReportValues turns the keys a site grouped by into the values of the
+// dimensions: looked up, converted to int for numeric keys, nil when missing.
+func getReportValues(dimensiuni []reportDimension, lookups []map[string]string, keys []sql.NullString) []interface{} {
+	valori := make([]interface{}, len(dimensiuni))
+	for i, dimensiune := range dimensiuni {
+		if !keys[i].Valid {
+			continue
+		}
+
+		switch {
+		case lookups[i] != nil:
+			valoare, ok := lookups[i][keys[i].String]
+			if ok {
+				valori[i] = valoare
+			}
+		case dimensiune.isInt:
+			valoare, err := strconv.Atoi(keys[i].String)
+			if err == nil {
+				valori[i] = valoare
+			}
+		default:
+			valori[i] = keys[i].String
+		}
+	}
+
+	return valori
+}
+
+// isReportRowBefore orders rows by their dimensions, in order, missing values
+// first.
+func isReportRowBefore(a []interface{}, b []interface{}) bool {
+	for i := range a {
+		switch {
+		case a[i] == nil && b[i] == nil:
+			continue
+		case a[i] == nil:
+			return true
+		case b[i] == nil:
+			return false
+		}
+
+		if first, ok := a[i].(int); ok {
+			second := b[i].(int)
+			if first != second {
+				return first < second
+			}
+			continue
+		}
+		if first, second := a[i].(string), b[i].(string); first != second {
+			return first < second
+		}
+	}
+
+	return false
+}
+
+func getCoduriParteneriFilter(coduriParteneri []string) sqlFilter {
+	var filter sqlFilter
+	if len(coduriParteneri) == 0 {
+		return *filter.where("1 = 0")
+	}
+
+	placeholders := make([]string, len(coduriParteneri))
+	args := make([]interface{}, len(coduriParteneri))
+	for i, cod := range coduriParteneri {
+		placeholders[i] = "?"
+		args[i] = cod
+	}
+
+	return *filter.where(fmt.Sprintf(`v."CodPartener" IN (%s)`, strings.Join(placeholders, ", ")), args...)
+}
+
+// getCoduriParteneri lists, through the list query, the codes of the partners
+// named numePartener on global, which is how sites filter on NumePartener; it
+// returns nil when numePartener is empty.
+func getCoduriParteneri(global Store, numePartener string) ([]string, error) {
+	if len(numePartener) == 0 {
+		return nil, nil
+	}
+
+	coduri := []string{}
+	params := repositories.ListParams{
+		Filtre:  []repositories.FiltruLista{{Camp: "NumePartener", Operator: "eq", Valoare: numePartener}},
+		Campuri: []string{"CodPartener"},
+		Limita:  maxListPageSize,
+	}
+	for {
+		pagina, err := global.GetLista("Parteneri", params)
+		if err != nil {
+			return nil, fmt.Errorf("could not look up partener %s: %w", numePartener, err)
+		}
+		for _, partener := range pagina.Rezultate {
+			coduri = append(coduri, fmt.Sprint(partener["CodPartener"]))
+		}
+
+		if len(pagina.CursorUrmator) == 0 {
+			return coduri, nil
+		}
+		params.Cursor = pagina.CursorUrmator
+	}
+}
+
+func getJudeteSucursale(global Store) (map[string]string, error) {
+	return global.GetJudeteSucursale()
+}
+
+// GetJudeteSucursale maps the IdSucursala of every branch to the county of its
+// address.
+func (client DBClient) GetJudeteSucursale() (map[string]string, error) {
+	rows, err := client.db.Query(fmt.Sprintf(`
+		SELECT s."IdSucursala", ad."Judet"
+		FROM "Sucursale%s" s, "Adrese%s" ad
+		WHERE s."IdAdresa" = ad."IdAdresa" AND ad."Judet" IS NOT NULL
+	`, client.tableSuffix, client.tableSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	judete := make(map[string]string)
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			IDSucursala int
+			judet       string
+		)
+		err := rows.Scan(&IDSucursala, &judet)
+		if err != nil {
+			return nil, err
+		}
+
+		judete[strconv.Itoa(IDSucursala)] = judet
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return judete, nil
+}
+
+func (agregat *AgregatRaport) add(other AgregatRaport) error {
+	platit, err := agregat.Platit.Add(other.Platit)
+	if err != nil {
+		return err
+	}
+
+	agregat.Platit = platit
+	agregat.Cantitate += other.Cantitate
+	agregat.SumaProcenteDiscount += other.SumaProcenteDiscount
+	agregat.NumarProcenteDiscount += other.NumarProcenteDiscount
+	agregat.NumarVanzari += other.NumarVanzari
+
+	return nil
+}
+
+func (agregat AgregatRaport) getMeasure(name string) interface{} {
+	switch name {
+	case MasuraPlatit:
+		return agregat.Platit
+	case MasuraCantitate:
+		return agregat.Cantitate
+	case MasuraProcentDiscount:
+		if agregat.NumarProcenteDiscount == 0 {
+			return nil
+		}
+		return math.Round(agregat.SumaProcenteDiscount/float64(agregat.NumarProcenteDiscount)*100) / 100
+	default:
+		return agregat.NumarVanzari
+	}
+}
@@ -0,0 +1,174 @@
+package datasources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"modbSalesApp/src/repositories"
+)
+
+var sucursalaSites = map[int]string{
+	1: Local1ConnectionName,
+	2: Local2ConnectionName,
+	3: Local3ConnectionName,
+	4: Local4ConnectionName,
+}
+
+// LoadSucursalaSites reads the IdSucursala to site mapping from a JSON file
+// such as {"1": "local1", "2": "local2"} and makes it the active routing.
+func LoadSucursalaSites(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var unmarshalledSites map[string]string
+	err = json.Unmarshal(content, &unmarshalledSites)
+	if err != nil {
+		return err
+	}
+
+	sites := make(map[int]string, len(unmarshalledSites))
+	for IDSucursala, site := range unmarshalledSites {
+		ID, err := strconv.Atoi(IDSucursala)
+		if err != nil {
+			return fmt.Errorf("IdSucursala '%s' is not an integer", IDSucursala)
+		}
+		sites[ID] = site
+	}
+
+	return SetSucursalaSites(sites)
+}
+
+func SetSucursalaSites(sites map[int]string) error {
+	for IDSucursala, site := range sites {
+		if site == GlobalConnectionName || !containsString(connectionNames, site) {
+			return fmt.Errorf("IdSucursala %d is routed to unknown local site '%s'", IDSucursala, site)
+		}
+	}
+
+	sucursalaSites = sites
+
+	return nil
+}
+
+func GetSucursalaSite(IDSucursala int) (string, error) {
+	site, ok := sucursalaSites[IDSucursala]
+	if !ok {
+		return "", fmt.Errorf("no site configured for IdSucursala %d", IDSucursala)
+	}
+
+	return site, nil
+}
+
+func getRoutedSites() []string {
+	var sites []string
+	for _, connectionName := range connectionNames {
+		for _, site := range sucursalaSites {
+			if site == connectionName {
+				sites = append(sites, connectionName)
+				break
+			}
+		}
+	}
+
+	return sites
+}
+
+func getSucursalaPredicate(predicateTemplate string, connectionName string, tableSuffix string) string {
+	var IDs []int
+	for IDSucursala, site := range sucursalaSites {
+		if site == connectionName {
+			IDs = append(IDs, IDSucursala)
+		}
+	}
+	if len(IDs) == 0 {
+		return "1 = 0"
+	}
+
+	sort.Ints(IDs)
+	values := make([]string, len(IDs))
+	for i, ID := range IDs {
+		values[i] = strconv.Itoa(ID)
+	}
+
+	return fmt.Sprintf(predicateTemplate, strings.Join(values, ", "), tableSuffix)
+}
+
+func GetSucursalaConnection(connections Connections, IDSucursala int) (Store, error) {
+	site, err := GetSucursalaSite(IDSucursala)
+	if err != nil {
+		return nil, err
+	}
+
+	client, ok := connections[site]
+	if !ok {
+		return nil, fmt.Errorf("no connection configured for site %s", site)
+	}
+
+	return client, nil
+}
+
+// GetRoutedConnections returns the connections of the local sites holding the
+// sales, in the order of the configured connections.
+func GetRoutedConnections(connections Connections) ([]Store, error) {
+	var clients []Store
+	for _, site := range getRoutedSites() {
+		client, ok := connections[site]
+		if !ok {
+			return nil, fmt.Errorf("no connection configured for site %s", site)
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+// GetVanzariFromAllSites reads a page of the sales of every local site, or
+// only of the site holding params.IDSucursala when it is given.
+func GetVanzariFromAllSites(connections Connections, params repositories.VanzariParams) (repositories.PaginaVanzari, error) {
+	sites := getRoutedSites()
+	if params.IDSucursala != 0 {
+		site, err := GetSucursalaSite(params.IDSucursala)
+		if err != nil {
+			return repositories.PaginaVanzari{Vanzari: []repositories.Vanzare{}}, nil
+		}
+		sites = []string{site}
+	}
+
+	siteConnections := make(Connections, len(sites))
+	for _, site := range sites {
+		client, ok := connections[site]
+		if !ok {
+			return repositories.PaginaVanzari{}, fmt.Errorf("no connection configured for site %s", site)
+		}
+		siteConnections[site] = client
+	}
+
+	return GetPaginaVanzari(siteConnections, params)
+}
+
+// GetVanzareConnection returns the local site holding the sale IDIntrare.
+func GetVanzareConnection(connections Connections, IDIntrare int) (Store, error) {
+	for _, site := range getRoutedSites() {
+		client, ok := connections[site]
+		if !ok {
+			return nil, fmt.Errorf("no connection configured for site %s", site)
+		}
+
+		_, err := client.GetVanzare(IDIntrare)
+		if err == nil {
+			return client, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("could not look for vanzarea %d on %s: %w", IDIntrare, site, err)
+		}
+	}
+
+	return nil, fmt.Errorf("vanzarea %d: %w", IDIntrare, ErrNotFound)
+}
@@ -0,0 +1,227 @@
+package datasources
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"modbSalesApp/src/repositories"
+)
+
+const (
+	searchLimit     = 10
+	maxSearchLimit  = 50
+	minSearchLength = 2
+)
+
+// Romanian diacritics, in both their comma and cedilla forms, and the letters
+// they are searched as.
+const (
+	searchDiacritics = "ĂÂÎȘŞȚŢăâîșşțţ"
+	searchLetters    = "AAISSTTaaisstt"
+)
+
+// Scores of a term found in a column: equal to it, at its start, at the start
+// of one of its words or anywhere in it.
+const (
+	searchScoreExact      = 100
+	searchScorePrefix     = 60
+	searchScoreWordPrefix = 40
+	searchScoreContains   = 20
+)
+
+// searchEntity is a table /search looks in: its key, the expression naming a
+// row and the columns matched against the query.
+type searchEntity struct {
+	table    string
+	key      string
+	denumire string
+	columns  []string
+}
+
+var (
+	searchParteneri = searchEntity{table: "Parteneri", key: "CodPartener", denumire: `"NumePartener"`, columns: []string{"NumePartener", "CUI"}}
+	searchArticole  = searchEntity{table: "Articole", key: "CodArticol", denumire: `"NumeArticol"`, columns: []string{"NumeArticol", "CodArticol"}}
+	searchVanzatori = searchEntity{table: "Vanzatori", key: "CodVanzator", denumire: `"Nume" || ' ' || "Prenume"`, columns: []string{"Nume", "Prenume"}}
+	searchSucursale = searchEntity{table: "Sucursale", key: "IdSucursala", denumire: `"NumeSucursala"`, columns: []string{"NumeSucursala"}}
+)
+
+// Search looks for query in the partners, articles, salespeople and branches
+// held on global, ignoring case and diacritics, and keeps the limita best
+// matches of each kind.
+func Search(connections Connections, query string, limita int) (repositories.RezultateCautare, error) {
+	termeni := getSearchTerms(query)
+	if len(strings.Join(termeni, "")) < minSearchLength {
+		return repositories.RezultateCautare{}, fmt.Errorf("q must have at least %d letters: %w", minSearchLength, ErrInvalidQuery)
+	}
+	if limita == 0 {
+		limita = searchLimit
+	}
+	if limita < 0 || limita > maxSearchLimit {
+		return repositories.RezultateCautare{}, fmt.Errorf("limit must be between 1 and %d: %w", maxSearchLimit, ErrInvalidQuery)
+	}
+
+	return connections[GlobalConnectionName].Search(termeni, limita)
+}
+
+// Search ranks the rows of this site where every term matches one of the
+// searched columns, best first; termeni are lower case and without
+// diacritics.
+func (client DBClient) Search(termeni []string, limita int) (repositories.RezultateCautare, error) {
+	var (
+		rezultate repositories.RezultateCautare
+		err       error
+	)
+
+	rezultate.Parteneri, err = client.searchEntity(searchParteneri, termeni, limita)
+	if err != nil {
+		return repositories.RezultateCautare{}, err
+	}
+	rezultate.Articole, err = client.searchEntity(searchArticole, termeni, limita)
+	if err != nil {
+		return repositories.RezultateCautare{}, err
+	}
+	rezultate.Vanzatori, err = client.searchEntity(searchVanzatori, termeni, limita)
+	if err != nil {
+		return repositories.RezultateCautare{}, err
+	}
+	rezultate.Sucursale, err = client.searchEntity(searchSucursale, termeni, limita)
+	if err != nil {
+		return repositories.RezultateCautare{}, err
+	}
+
+	return rezultate, nil
+}
+
+// searchEntity scores the matching rows in the query itself, so the limit keeps
+// the best of them rather than the first ones by key.
+func (client DBClient) searchEntity(entity searchEntity, termeni []string, limita int) ([]repositories.RezultatCautare, error) {
+	var (
+		filter    sqlFilter
+		scores    []string
+		scoreArgs []interface{}
+	)
+	for _, termen := range termeni {
+		conditions := make([]string, len(entity.columns))
+		args := make([]interface{}, len(entity.columns))
+		for i, column := range entity.columns {
+			conditions[i] = fmt.Sprintf(`%s LIKE ? ESCAPE '\'`, getSearchColumn(column))
+			args[i] = "%" + escapeLike(termen) + "%"
+		}
+		filter.where(fmt.Sprintf("(%s)", strings.Join(conditions, " OR ")), args...)
+
+		score, args := getSearchScoreStatement(entity.columns, termen)
+		scores = append(scores, score)
+		scoreArgs = append(scoreArgs, args...)
+	}
+
+	rows, err := client.db.Query(
+		bindPlaceholders(fmt.Sprintf(
+			`SELECT "%s", %s Denumire, %s, %s Scor FROM "%s%s" %s ORDER BY Scor DESC, Denumire, "%s" OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY`,
+			entity.key, entity.denumire, strings.Join(quoteColumns(entity.columns), ", "), strings.Join(scores, " + "),
+			entity.table, client.tableSuffix, filter.whereStatement(), entity.key, limita,
+		)),
+		append(scoreArgs, filter.args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rezultate := []repositories.RezultatCautare{}
+
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cheie    string
+			denumire sql.NullString
+			scor     int
+		)
+		values := make([]sql.NullString, len(entity.columns))
+		destinations := []interface{}{&cheie, &denumire}
+		for i := range values {
+			destinations = append(destinations, &values[i])
+		}
+		destinations = append(destinations, &scor)
+
+		err := rows.Scan(destinations...)
+		if err != nil {
+			return nil, err
+		}
+
+		rezultat := repositories.RezultatCautare{Cheie: cheie, Denumire: denumire.String, Campuri: []string{}, Scor: scor}
+		for i, column := range entity.columns {
+			value := normalizeSearchText(values[i].String)
+			for _, termen := range termeni {
+				if strings.Contains(value, termen) {
+					rezultat.Campuri = append(rezultat.Campuri, getJSONFieldName(column))
+					break
+				}
+			}
+		}
+		rezultate = append(rezultate, rezultat)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return rezultate, nil
+}
+
+// getSearchColumn folds the case and diacritics of column the way
+// normalizeSearchText folds the query.
+func getSearchColumn(column string) string {
+	return fmt.Sprintf(`LOWER(TRANSLATE("%s", '%s', '%s'))`, column, searchDiacritics, searchLetters)
+}
+
+// getSearchScoreStatement scores termen by its best match in any of columns:
+// equal to the column, at its start, at the start of one of its words or
+// anywhere in it.
+func getSearchScoreStatement(columns []string, termen string) (string, []interface{}) {
+	matches := []struct {
+		score    int
+		patterns []string
+	}{
+		{score: searchScoreExact, patterns: []string{escapeLike(termen)}},
+		{score: searchScorePrefix, patterns: []string{escapeLike(termen) + "%"}},
+		{score: searchScoreWordPrefix, patterns: []string{"% " + escapeLike(termen) + "%", "%-" + escapeLike(termen) + "%"}},
+		{score: searchScoreContains, patterns: []string{"%" + escapeLike(termen) + "%"}},
+	}
+
+	var (
+		cases []string
+		args  []interface{}
+	)
+	for _, match := range matches {
+		var conditions []string
+		for _, column := range columns {
+			for _, pattern := range match.patterns {
+				conditions = append(conditions, fmt.Sprintf(`%s LIKE ? ESCAPE '\'`, getSearchColumn(column)))
+				args = append(args, pattern)
+			}
+		}
+		cases = append(cases, fmt.Sprintf("WHEN %s THEN %d", strings.Join(conditions, " OR "), match.score))
+	}
+
+	return fmt.Sprintf("(CASE %s ELSE 0 END)", strings.Join(cases, " ")), args
+}
+
+func getSearchTerms(query string) []string {
+	var termeni []string
+	for _, termen := range strings.Fields(normalizeSearchText(query)) {
+		if !containsString(termeni, termen) {
+			termeni = append(termeni, termen)
+		}
+	}
+
+	return termeni
+}
+
+func normalizeSearchText(text string) string {
+	return strings.ToLower(diacriticsReplacer.Replace(text))
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
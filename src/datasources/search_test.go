@@ -0,0 +1,116 @@
+package datasources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetSearchTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "Ștefan", want: []string{"stefan"}},
+		{query: "Ştefan", want: []string{"stefan"}},
+		{query: "Țară ţară", want: []string{"tara"}},
+		{query: "ÎNCĂLȚĂMINTE încălţăminte", want: []string{"incaltaminte"}},
+		{query: "  Brașov   Iaşi ", want: []string{"brasov", "iasi"}},
+	}
+
+	for _, test := range tests {
+		got := getSearchTerms(test.query)
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("getSearchTerms(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func getSearchTestClient(t *testing.T) (DBClient, func()) {
+	dir, err := ioutil.TempDir("", "search")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	connections := GetSQLiteConnections(filepath.Join(dir, "search.db"))
+	client, err := getSQLClient(connections[GlobalConnectionName])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func insertSearchTestPartener(t *testing.T, client DBClient, codPartener string, numePartener string) {
+	_, err := client.db.Exec(`INSERT INTO "Parteneri"("CodPartener", "NumePartener") VALUES(:1, :2)`, codPartener, numePartener)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchFoldsDiacritics(t *testing.T) {
+	client, cleanup := getSearchTestClient(t)
+	defer cleanup()
+
+	insertSearchTestPartener(t, client, "p1", "Ştefănescu SRL")
+	insertSearchTestPartener(t, client, "p2", "Ștefănescu Prod")
+	insertSearchTestPartener(t, client, "p3", "Ţesătoria Nouă")
+	insertSearchTestPartener(t, client, "p4", "Țesături Iaşi")
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "stefanescu", want: []string{"p1", "p2"}},
+		{query: "Ştefănescu", want: []string{"p1", "p2"}},
+		{query: "Ștefănescu srl", want: []string{"p1"}},
+		{query: "tes", want: []string{"p3", "p4"}},
+		{query: "ţesături", want: []string{"p4"}},
+		{query: "iași", want: []string{"p4"}},
+	}
+
+	for _, test := range tests {
+		rezultate, err := client.Search(getSearchTerms(test.query), searchLimit)
+		if err != nil {
+			t.Fatalf("%q: %v", test.query, err)
+		}
+
+		var got []string
+		for _, rezultat := range rezultate.Parteneri {
+			got = append(got, rezultat.Cheie)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("Search(%q) = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSearchRanksBeforeLimit(t *testing.T) {
+	client, cleanup := getSearchTestClient(t)
+	defer cleanup()
+
+	// The best matches come last by key, after hundreds of weaker ones.
+	for i := 0; i < 600; i++ {
+		insertSearchTestPartener(t, client, fmt.Sprintf("a%03d", i), fmt.Sprintf("Firma Popescu %03d", i))
+	}
+	insertSearchTestPartener(t, client, "z1", "Pop Ana")
+	insertSearchTestPartener(t, client, "z2", "Pop")
+
+	rezultate, err := client.Search(getSearchTerms("pop"), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, rezultat := range rezultate.Parteneri {
+		got = append(got, fmt.Sprintf("%s:%d", rezultat.Cheie, rezultat.Scor))
+	}
+	want := []string{"z2:100", "z1:60", "a000:40"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Search(pop) = %v, want %v", got, want)
+	}
+}
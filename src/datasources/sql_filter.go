@@ -0,0 +1,164 @@
+package datasources
+
+import (
+	"fmt"
+	"strings"
+
+	"modbSalesApp/src/repositories"
+)
+
+// sqlFilter collects the conditions of a WHERE clause together with their bind
+// values and the tables they need joined in. Conditions use ? for each value;
+// bindPlaceholders numbers them once the whole query is assembled, so a filter
+// can be placed several times in the same query.
+type sqlFilter struct {
+	tables     []string
+	conditions []string
+	args       []interface{}
+}
+
+func (f *sqlFilter) where(condition string, args ...interface{}) *sqlFilter {
+	f.conditions = append(f.conditions, condition)
+	f.args = append(f.args, args...)
+
+	return f
+}
+
+// join adds table to the FROM clause, unless it is already there, and
+// condition to the WHERE clause.
+func (f *sqlFilter) join(table string, condition string, args ...interface{}) *sqlFilter {
+	if !containsString(f.tables, table) {
+		f.tables = append(f.tables, table)
+	}
+
+	return f.where(condition, args...)
+}
+
+func (f *sqlFilter) and(other sqlFilter) *sqlFilter {
+	for _, table := range other.tables {
+		if !containsString(f.tables, table) {
+			f.tables = append(f.tables, table)
+		}
+	}
+	f.conditions = append(f.conditions, other.conditions...)
+	f.args = append(f.args, other.args...)
+
+	return f
+}
+
+func (f sqlFilter) from(fromStatement string) string {
+	if len(f.tables) == 0 {
+		return fromStatement
+	}
+
+	return fmt.Sprintf("%s, %s", fromStatement, strings.Join(f.tables, ", "))
+}
+
+func (f sqlFilter) whereStatement() string {
+	if len(f.conditions) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("WHERE %s", strings.Join(f.conditions, " AND "))
+}
+
+// bindPlaceholders turns every ? of query into :1, :2, ... in order of
+// appearance, the form both go-ora and go-sqlite3 bind positionally.
+func bindPlaceholders(query string) string {
+	var (
+		bound strings.Builder
+		index int
+	)
+
+	for _, char := range query {
+		if char != '?' {
+			bound.WriteRune(char)
+			continue
+		}
+
+		index++
+		bound.WriteString(fmt.Sprintf(":%d", index))
+	}
+
+	return bound.String()
+}
+
+func repeatArgs(args []interface{}, times int) []interface{} {
+	repeated := make([]interface{}, 0, len(args)*times)
+	for i := 0; i < times; i++ {
+		repeated = append(repeated, args...)
+	}
+
+	return repeated
+}
+
+func getDateRangeFilter(column string, dataStart string, dataEnd string) sqlFilter {
+	var filter sqlFilter
+
+	if len(dataStart) > 0 {
+		filter.where(fmt.Sprintf(`%s >= TO_DATE(?, 'MM/DD/YYYY')`, column), dataStart)
+	}
+	if len(dataEnd) > 0 {
+		filter.where(fmt.Sprintf(`%s <= TO_DATE(?, 'MM/DD/YYYY')`, column), dataEnd)
+	}
+
+	return filter
+}
+
+// getVanzariParamsFilter filters the sales of a site on the query parameters of
+// /vanzari.
+func getVanzariParamsFilter(params repositories.VanzariParams) sqlFilter {
+	var filter sqlFilter
+
+	if len(params.CodPartener) > 0 {
+		filter.where(`"CodPartener" = ?`, params.CodPartener)
+	}
+	if params.CodVanzator != 0 {
+		filter.where(`"CodVanzator" = ?`, params.CodVanzator)
+	}
+	if params.IDSucursala != 0 {
+		filter.where(`"IdSucursala" = ?`, params.IDSucursala)
+	}
+	if len(params.Status) > 0 {
+		filter.where(`"Status" = ?`, params.Status)
+	}
+	if len(params.Moneda) > 0 {
+		filter.where(`"Moneda" = ?`, params.Moneda)
+	}
+	filter.and(getDateRangeFilter(`"Data"`, params.DataStart, params.DataEnd))
+	filter.and(getDateRangeFilter(`"DataLivrare"`, params.DataLivrareStart, params.DataLivrareEnd))
+
+	return filter
+}
+
+// getFormParamsFilter filters sales aliased v on the fields of the report form.
+// With linii, the query also reads their lines, aliased lv, and NumeArticol
+// keeps only the lines of that article; without, it keeps the sales having a
+// line of it, each once. Partner names are only held on global and local1, so
+// NumePartener is given as coduriParteneri, the codes of the partners with that
+// name, and nil leaves partners unfiltered.
+func getFormParamsFilter(params repositories.FormParams, coduriParteneri []string, tableSuffix string, linii bool) sqlFilter {
+	var filter sqlFilter
+
+	if params.CodVanzator != 0 {
+		filter.where(`v."CodVanzator" = ?`, params.CodVanzator)
+	}
+	if len(params.NumeArticol) > 0 && linii {
+		filter.join(fmt.Sprintf(`"Articole%s" a`, tableSuffix), `lv."CodArticol" = a."CodArticol" AND a."NumeArticol" = ?`, params.NumeArticol)
+	}
+	if len(params.NumeArticol) > 0 && !linii {
+		filter.where(fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM "LiniiVanzari%s" fl, "Articole%s" fa
+			WHERE fl."IdIntrare" = v."IdIntrare" AND fl."CodArticol" = fa."CodArticol" AND fa."NumeArticol" = ?
+		)`, tableSuffix, tableSuffix), params.NumeArticol)
+	}
+	if len(params.NumeSucursala) > 0 {
+		filter.join(fmt.Sprintf(`"Sucursale%s" s`, tableSuffix), `v."IdSucursala" = s."IdSucursala" AND s."NumeSucursala" = ?`, params.NumeSucursala)
+	}
+	if coduriParteneri != nil {
+		filter.and(getCoduriParteneriFilter(coduriParteneri))
+	}
+	filter.and(getDateRangeFilter(`v."Data"`, params.DataStart, params.DataEnd))
+
+	return filter
+}
@@ -0,0 +1,63 @@
+package datasources
+
+import (
+	"database/sql"
+	"fmt"
+
+	"modbSalesApp/src/repositories"
+)
+
+type SQLiteClient struct {
+	DBClient
+}
+
+func GetSQLiteConnections(path string) Connections {
+	db, err := sql.Open(
+		sqliteDriverName,
+		fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate", path),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	err = createSQLiteSchema(db)
+	if err != nil {
+		panic(err)
+	}
+
+	connections := make(Connections, len(connectionNames))
+	for _, connectionName := range connectionNames {
+		connections[connectionName] = GetSQLiteClient(db, connectionName)
+	}
+
+	return connections
+}
+
+func GetSQLiteClient(db *sql.DB, connectionName string) SQLiteClient {
+	return SQLiteClient{
+		DBClient: DBClient{
+			db:          sqliteDB{db},
+			name:        connectionName,
+			tableSuffix: getTableSuffix(connectionName),
+		},
+	}
+}
+
+// The Oracle query averages a SUM that is correlated down to a single group,
+// which SQLite cannot nest; the sum of that one group is the same value.
+func (client SQLiteClient) GetCantitateLivrataZile(params repositories.FormParams, coduriParteneri []string) ([]repositories.CantitateLivrataZile, error) {
+	filter, subQueryFilter := getCantitateLivrataZileFilters(params, coduriParteneri, client.tableSuffix)
+
+	query := fmt.Sprintf(`
+		SELECT (
+			SELECT NVL(SUM(lv."Cantitate"), 0)
+			%s
+			%s
+		) CantitateMedieLivrata, TO_CHAR(vl."DataLivrare", 'DY') ZiSaptamana
+		FROM (SELECT v."DataLivrare" %s %s) vl
+		GROUP BY TO_CHAR(vl."DataLivrare", 'DY')
+	`, subQueryFilter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), subQueryFilter.whereStatement(),
+		filter.from(fmt.Sprintf(`FROM "Vanzari%s" v`, client.tableSuffix)), filter.whereStatement())
+
+	return client.queryCantitateLivrataZile(query, subQueryFilter.and(filter).args)
+}
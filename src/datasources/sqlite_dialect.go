@@ -0,0 +1,271 @@
+package datasources
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const sqliteDriverName = "sqlite3_modb"
+
+// sqliteDB runs the Oracle flavoured SQL written for DBClient against SQLite,
+// rewriting the few constructs SQLite does not understand. TO_DATE and TO_CHAR
+// are registered as functions on every new connection instead.
+type (
+	sqliteDB struct {
+		*sql.DB
+	}
+
+	sqliteTx struct {
+		*sql.Tx
+	}
+)
+
+var (
+	nvlRegexp       = regexp.MustCompile(`\bNVL\(`)
+	fetchNextRegexp = regexp.MustCompile(`OFFSET\s+(\d+)\s+ROWS\s+FETCH\s+NEXT\s+(\d+)\s+ROWS\s+ONLY`)
+	extractRegexp   = regexp.MustCompile(`EXTRACT\(\s*(YEAR|MONTH|DAY)\s+FROM\s+([^)]+)\)`)
+
+	extractFormats = map[string]string{
+		"YEAR":  "%Y",
+		"MONTH": "%m",
+		"DAY":   "%d",
+	}
+
+	oracleFormatTokens = []string{"HH24", "IYYY", "YYYY", "MM", "DD", "DY", "MI", "SS", "IW", "Q"}
+	sqliteDateLayouts  = []string{"2006-01-02", "2006-01-02 15:04:05", time.RFC3339}
+)
+
+func init() {
+	sql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			err := conn.RegisterFunc("TO_DATE", sqliteToDate, true)
+			if err != nil {
+				return err
+			}
+
+			err = conn.RegisterFunc("TO_CHAR", sqliteToChar, true)
+			if err != nil {
+				return err
+			}
+
+			return conn.RegisterFunc("TRANSLATE", sqliteTranslate, true)
+		},
+	})
+}
+
+func (db sqliteDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.Query(translateOracleSQL(query), args...)
+}
+
+func (db sqliteDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.Exec(translateOracleSQL(query), args...)
+}
+
+func (db sqliteDB) Prepare(query string) (*sql.Stmt, error) {
+	return db.DB.Prepare(translateOracleSQL(query))
+}
+
+func (db sqliteDB) Begin() (transaction, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	return sqliteTx{tx}, nil
+}
+
+func (tx sqliteTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(translateOracleSQL(query), args...)
+}
+
+func (tx sqliteTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(translateOracleSQL(query), args...)
+}
+
+func translateOracleSQL(query string) string {
+	query = nvlRegexp.ReplaceAllString(query, "IFNULL(")
+	query = fetchNextRegexp.ReplaceAllString(query, "LIMIT $2 OFFSET $1")
+	query = extractRegexp.ReplaceAllStringFunc(query, func(match string) string {
+		parts := extractRegexp.FindStringSubmatch(match)
+		return fmt.Sprintf("CAST(strftime('%s', %s) AS INTEGER)", extractFormats[parts[1]], parts[2])
+	})
+
+	return query
+}
+
+func sqliteToDate(value interface{}, format string) (string, error) {
+	text := sqliteText(value)
+	if len(text) == 0 {
+		return "", nil
+	}
+
+	date, err := time.Parse(getGoLayout(format), text)
+	if err != nil {
+		return "", err
+	}
+
+	return formatSQLiteDate(date), nil
+}
+
+func sqliteToChar(value interface{}, format string) (string, error) {
+	text := sqliteText(value)
+	if len(text) == 0 {
+		return "", nil
+	}
+
+	for _, layout := range sqliteDateLayouts {
+		date, err := time.Parse(layout, text)
+		if err == nil {
+			return formatOracleDate(date, format), nil
+		}
+	}
+
+	return text, nil
+}
+
+// sqliteTranslate replaces every character of from in value with the
+// character at the same position in to, or drops it when to is shorter, as
+// Oracle's TRANSLATE does.
+func sqliteTranslate(value interface{}, from string, to string) (string, error) {
+	toRunes := []rune(to)
+	replacements := make(map[rune]int)
+	for i, char := range []rune(from) {
+		if _, ok := replacements[char]; !ok {
+			replacements[char] = i
+		}
+	}
+
+	var translated strings.Builder
+	for _, char := range sqliteText(value) {
+		i, ok := replacements[char]
+		if !ok {
+			translated.WriteRune(char)
+		} else if i < len(toRunes) {
+			translated.WriteRune(toRunes[i])
+		}
+	}
+
+	return translated.String(), nil
+}
+
+func sqliteText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func formatSQLiteDate(date time.Time) string {
+	if date.Hour() == 0 && date.Minute() == 0 && date.Second() == 0 {
+		return date.Format("2006-01-02")
+	}
+
+	return date.Format("2006-01-02 15:04:05")
+}
+
+func formatOracleDate(date time.Time, format string) string {
+	var result strings.Builder
+
+	forEachOracleFormatToken(format, func(token string, isLiteral bool) {
+		if isLiteral {
+			result.WriteString(token)
+			return
+		}
+
+		isoYear, isoWeek := date.ISOWeek()
+		switch token {
+		case "YYYY":
+			result.WriteString(fmt.Sprintf("%04d", date.Year()))
+		case "IYYY":
+			result.WriteString(fmt.Sprintf("%04d", isoYear))
+		case "MM":
+			result.WriteString(fmt.Sprintf("%02d", int(date.Month())))
+		case "DD":
+			result.WriteString(fmt.Sprintf("%02d", date.Day()))
+		case "DY":
+			result.WriteString(strings.ToUpper(date.Format("Mon")))
+		case "HH24":
+			result.WriteString(fmt.Sprintf("%02d", date.Hour()))
+		case "MI":
+			result.WriteString(fmt.Sprintf("%02d", date.Minute()))
+		case "SS":
+			result.WriteString(fmt.Sprintf("%02d", date.Second()))
+		case "IW":
+			result.WriteString(fmt.Sprintf("%02d", isoWeek))
+		case "Q":
+			result.WriteString(fmt.Sprintf("%d", (int(date.Month())-1)/3+1))
+		}
+	})
+
+	return result.String()
+}
+
+func getGoLayout(format string) string {
+	var layout strings.Builder
+
+	forEachOracleFormatToken(format, func(token string, isLiteral bool) {
+		if isLiteral {
+			layout.WriteString(token)
+			return
+		}
+
+		switch token {
+		case "YYYY", "IYYY":
+			layout.WriteString("2006")
+		case "MM":
+			layout.WriteString("1")
+		case "DD":
+			layout.WriteString("2")
+		case "DY":
+			layout.WriteString("Mon")
+		case "HH24":
+			layout.WriteString("15")
+		case "MI":
+			layout.WriteString("04")
+		case "SS":
+			layout.WriteString("05")
+		}
+	})
+
+	return layout.String()
+}
+
+func forEachOracleFormatToken(format string, handle func(token string, isLiteral bool)) {
+	for i := 0; i < len(format); {
+		if format[i] == '"' {
+			end := strings.IndexByte(format[i+1:], '"')
+			if end < 0 {
+				handle(format[i+1:], true)
+				return
+			}
+			handle(format[i+1:i+1+end], true)
+			i += end + 2
+			continue
+		}
+
+		matched := false
+		for _, token := range oracleFormatTokens {
+			if strings.HasPrefix(strings.ToUpper(format[i:]), token) {
+				handle(token, false)
+				i += len(token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			handle(format[i:i+1], true)
+			i++
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package datasources
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type (
+	sqliteColumn struct {
+		name       string
+		definition string
+	}
+
+	sqliteTable struct {
+		name        string
+		columns     []sqliteColumn
+		constraints []string
+		globalOnly  bool
+	}
+)
+
+var sqliteTables = []sqliteTable{
+	{
+		name: "Adrese",
+		columns: []sqliteColumn{
+			{"IdAdresa", "INTEGER PRIMARY KEY"},
+			{"NumeAdresa", "VARCHAR2(100)"},
+			{"Oras", "VARCHAR2(100)"},
+			{"Judet", "VARCHAR2(100)"},
+			{"Sector", "VARCHAR2(50)"},
+			{"Strada", "VARCHAR2(100)"},
+			{"Numar", "VARCHAR2(20)"},
+			{"Bloc", "VARCHAR2(20)"},
+			{"Etaj", "NUMBER(3)"},
+		},
+	},
+	{
+		name: "Parteneri",
+		columns: []sqliteColumn{
+			{"CodPartener", "VARCHAR2(50) PRIMARY KEY"},
+			{"NumePartener", "VARCHAR2(100)"},
+			{"CUI", "VARCHAR2(20)"},
+			{"EMail", "VARCHAR2(100)"},
+			{"IdAdresa", "NUMBER(10)"},
+		},
+	},
+	{
+		name: "Vanzatori",
+		columns: []sqliteColumn{
+			{"CodVanzator", "INTEGER PRIMARY KEY"},
+			{"Nume", "VARCHAR2(100)"},
+			{"Prenume", "VARCHAR2(100)"},
+			{"SalariuBaza", "NUMBER(10, 2)"},
+			{"Comision", "NUMBER(10, 2)"},
+			{"EMail", "VARCHAR2(100)"},
+			{"IdAdresa", "NUMBER(10)"},
+			{"Activ", "CHAR(1) DEFAULT 'Y' NOT NULL"},
+		},
+	},
+	{
+		name: "Sucursale",
+		columns: []sqliteColumn{
+			{"IdSucursala", "INTEGER PRIMARY KEY"},
+			{"NumeSucursala", "VARCHAR2(100)"},
+			{"IdAdresa", "NUMBER(10)"},
+		},
+	},
+	{
+		name: "GrupaArticole",
+		columns: []sqliteColumn{
+			{"CodGrupa", "INTEGER PRIMARY KEY"},
+			{"NumeGrupa", "VARCHAR2(100)"},
+			{"DetaliiGrupa", "VARCHAR2(500)"},
+		},
+	},
+	{
+		name: "UnitatiDeMasura",
+		columns: []sqliteColumn{
+			{"IdUnitateDeMasura", "INTEGER PRIMARY KEY"},
+			{"NumeUnitateDeMasura", "VARCHAR2(50)"},
+			{"Inaltime", "NUMBER(10, 2)"},
+			{"Latime", "NUMBER(10, 2)"},
+			{"Lungime", "NUMBER(10, 2)"},
+		},
+	},
+	{
+		name: "Articole",
+		columns: []sqliteColumn{
+			{"CodArticol", "VARCHAR2(50) PRIMARY KEY"},
+			{"NumeArticol", "VARCHAR2(100)"},
+			{"CodGrupa", "NUMBER(10)"},
+			{"CantitateStoc", "NUMBER(10)"},
+			{"IdUnitateDeMasura", "NUMBER(10)"},
+		},
+	},
+	{
+		name: "Proiecte",
+		columns: []sqliteColumn{
+			{"IdProiect", "VARCHAR2(50) PRIMARY KEY"},
+			{"NumeProiect", "VARCHAR2(100)"},
+			{"ValidDeLa", "DATE"},
+			{"ValidPanaLa", "DATE"},
+			{"Activ", "CHAR(1)"},
+		},
+	},
+	{
+		name: "Vanzari",
+		columns: []sqliteColumn{
+			{"IdIntrare", "INTEGER PRIMARY KEY"},
+			{"CodPartener", "VARCHAR2(50)"},
+			{"Status", "VARCHAR2(20)"},
+			{"Data", "DATE"},
+			{"DataLivrare", "DATE"},
+			{"Total", "NUMBER(12, 2)"},
+			{"Vat", "NUMBER(12, 2)"},
+			{"Discount", "NUMBER(12, 2)"},
+			{"Moneda", "VARCHAR2(10)"},
+			{"Platit", "NUMBER(12, 2)"},
+			{"Comentarii", "VARCHAR2(500)"},
+			{"CodVanzator", "NUMBER(10)"},
+			{"IdSucursala", "NUMBER(10)"},
+		},
+	},
+	{
+		name: "LiniiVanzari",
+		columns: []sqliteColumn{
+			{"IdIntrare", "NUMBER(10)"},
+			{"NumarLinie", "NUMBER(10)"},
+			{"CodArticol", "VARCHAR2(50)"},
+			{"Cantitate", "NUMBER(10, 2)"},
+			{"Pret", "NUMBER(12, 2)"},
+			{"Discount", "NUMBER(12, 2)"},
+			{"Vat", "NUMBER(12, 2)"},
+			{"TotalLinie", "NUMBER(12, 2)"},
+			{"IdProiect", "VARCHAR2(50)"},
+			{"StocScazut", "CHAR(1)"},
+		},
+		constraints: []string{`PRIMARY KEY ("IdIntrare", "NumarLinie")`},
+	},
+	{
+		name: "IstoricStatusVanzari",
+		columns: []sqliteColumn{
+			{"IdIntrare", "NUMBER(10)"},
+			{"NumarTranzitie", "NUMBER(10)"},
+			{"StatusVechi", "VARCHAR2(20)"},
+			{"StatusNou", "VARCHAR2(20)"},
+			{"Data", "DATE"},
+			{"CodVanzator", "NUMBER(10)"},
+		},
+		constraints: []string{`PRIMARY KEY ("IdIntrare", "NumarTranzitie")`},
+	},
+	{
+		name: "TranzactiiDistribuite",
+		columns: []sqliteColumn{
+			{"IdTranzactie", "VARCHAR2(64) PRIMARY KEY"},
+		},
+	},
+	{
+		name: "IstoricVanzatori",
+		columns: []sqliteColumn{
+			{"CodVanzator", "NUMBER(10)"},
+			{"DataInceput", "DATE"},
+			{"SalariuBaza", "NUMBER(10, 2)"},
+			{"Comision", "NUMBER(10, 2)"},
+		},
+		constraints: []string{`PRIMARY KEY ("CodVanzator", "DataInceput")`},
+		globalOnly:  true,
+	},
+	{
+		name: "MiscariStoc",
+		columns: []sqliteColumn{
+			{"IdMiscare", "INTEGER PRIMARY KEY"},
+			{"CodArticol", "VARCHAR2(50)"},
+			{"Motiv", "VARCHAR2(20)"},
+			{"Cantitate", "NUMBER(10)"},
+			{"Diferenta", "NUMBER(10)"},
+			{"CantitateStoc", "NUMBER(10)"},
+			{"Data", "DATE"},
+			{"Comentarii", "VARCHAR2(500)"},
+		},
+		globalOnly: true,
+	},
+	{
+		name: "Secvente",
+		columns: []sqliteColumn{
+			{"NumeSecventa", "VARCHAR2(50) PRIMARY KEY"},
+			{"UltimaValoare", "NUMBER(10)"},
+		},
+		globalOnly: true,
+	},
+}
+
+func createSQLiteSchema(db *sql.DB) error {
+	for _, connectionName := range connectionNames {
+		for _, table := range sqliteTables {
+			if table.globalOnly && connectionName != GlobalConnectionName {
+				continue
+			}
+
+			_, err := db.Exec(getCreateTableStatement(table, connectionName))
+			if err != nil {
+				return fmt.Errorf("could not create table %s%s: %w", table.name, getTableSuffix(connectionName), err)
+			}
+
+			err = addMissingSQLiteColumns(db, table, connectionName)
+			if err != nil {
+				return fmt.Errorf("could not add columns to table %s%s: %w", table.name, getTableSuffix(connectionName), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// addMissingSQLiteColumns adds the columns a table created by an older version
+// lacks; the rows it already holds get NULL in them.
+func addMissingSQLiteColumns(db *sql.DB, table sqliteTable, connectionName string) error {
+	tableName := table.name + getTableSuffix(connectionName)
+	rows, err := db.Query(fmt.Sprintf(`SELECT "name" FROM pragma_table_info('%s')`, tableName))
+	if err != nil {
+		return err
+	}
+
+	var existing []string
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		existing = append(existing, name)
+	}
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	_, isFragmented := fragmentationCatalog[table.name]
+	fragmentColumns := getFragmentColumns(table.name, connectionName)
+	for _, column := range table.columns {
+		if containsString(existing, column.name) || (isFragmented && !containsString(fragmentColumns, column.name)) {
+			continue
+		}
+
+		_, err := db.Exec(fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s`, tableName, column.name, column.definition))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getCreateTableStatement(table sqliteTable, connectionName string) string {
+	var definitions []string
+
+	_, isFragmented := fragmentationCatalog[table.name]
+	fragmentColumns := getFragmentColumns(table.name, connectionName)
+	for _, column := range table.columns {
+		if isFragmented && !containsString(fragmentColumns, column.name) {
+			continue
+		}
+		definitions = append(definitions, fmt.Sprintf(`"%s" %s`, column.name, column.definition))
+	}
+	definitions = append(definitions, table.constraints...)
+
+	return fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS "%s%s" (%s)`,
+		table.name,
+		getTableSuffix(connectionName),
+		strings.Join(definitions, ", "),
+	)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package datasources
+
+import "strings"
+
+// Status of a sale. A sale starts as ciorna and only moves along
+// vanzareTransitions; anulata and platita are final.
+const (
+	VanzareCiorna     = "ciorna"
+	VanzareConfirmata = "confirmata"
+	VanzareExpediata  = "expediata"
+	VanzareLivrata    = "livrata"
+	VanzarePlatita    = "platita"
+	VanzareAnulata    = "anulata"
+)
+
+var vanzareTransitions = map[string][]string{
+	VanzareCiorna:     {VanzareConfirmata, VanzareAnulata},
+	VanzareConfirmata: {VanzareExpediata, VanzareAnulata},
+	VanzareExpediata:  {VanzareLivrata, VanzareAnulata},
+	VanzareLivrata:    {VanzarePlatita, VanzareAnulata},
+	VanzarePlatita:    {},
+	VanzareAnulata:    {},
+}
+
+func IsStatusVanzare(status string) bool {
+	_, ok := vanzareTransitions[status]
+
+	return ok
+}
+
+func canTransitionVanzare(statusVechi string, statusNou string) bool {
+	for _, status := range vanzareTransitions[statusVechi] {
+		if status == statusNou {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getAllowedTransitions lists the statuses statusVechi can move to, for error
+// messages.
+func getAllowedTransitions(statusVechi string) string {
+	if len(vanzareTransitions[statusVechi]) == 0 {
+		return "none"
+	}
+
+	return strings.Join(vanzareTransitions[statusVechi], ", ")
+}
@@ -0,0 +1,69 @@
+package datasources
+
+import (
+	"modbSalesApp/src/repositories"
+)
+
+type Store interface {
+	GetParteneri() ([]repositories.Partener, error)
+	GetPartener(codPartener string) (repositories.Partener, error)
+	InsertPartener(partenerAdresa repositories.InsertPartener, connections Connections) error
+	EditPartener(partenerAdresa repositories.InsertPartener, connections Connections) error
+	DeletePartener(codPartener string, connections Connections) error
+	CountVanzariPartener(codPartener string) (int, error)
+
+	GetAdrese() ([]repositories.Adresa, error)
+	InsertAdresa(adresa repositories.Adresa) (int, error)
+
+	GetVanzari(params repositories.VanzariParams, cursor *VanzariCursor) ([]VanzareSortata, error)
+	CountVanzari(params repositories.VanzariParams) (int, error)
+	GetVanzare(IDIntrare int) (repositories.Vanzare, error)
+	InsertVanzare(vanzareLinii repositories.InsertVanzare, connections Connections) error
+	EditVanzare(vanzare repositories.Vanzare) error
+	TransitionVanzare(tranzitie repositories.TranzitieVanzare, connections Connections) (repositories.TranzitieVanzare, error)
+	GetIstoricStatusVanzare(IDIntrare int) ([]repositories.TranzitieVanzare, error)
+
+	GetLiniiVanzare(IDIntrareVanzari int) ([]repositories.LinieVanzare, error)
+	InsertLinieVanzare(linie repositories.LinieVanzare, connections Connections) error
+	EditLinieVanzare(linie repositories.LinieVanzare, connections Connections) error
+	DeleteLinieVanzare(IDIntrare int, numarLinie int, connections Connections) error
+
+	GetArticole() ([]repositories.Articol, error)
+	InsertArticol(articol repositories.Articol, connections Connections) error
+	EditArticol(articol repositories.Articol, connections Connections) error
+	DeleteArticol(codArticol string, connections Connections) error
+	CountLiniiVanzariArticol(codArticol string) (int, error)
+	GetMiscariStoc(codArticol string) ([]repositories.MiscareStoc, error)
+	InsertMiscareStoc(miscare repositories.MiscareStoc, connections Connections) (repositories.MiscareStoc, error)
+
+	GetVanzatori() ([]repositories.Vanzator, error)
+	GetVanzator(codVanzator int) (repositories.Vanzator, error)
+	InsertVanzator(vanzatorAdresa repositories.InsertVanzator, connections Connections) error
+	EditVanzator(vanzatorAdresa repositories.InsertVanzator, connections Connections) error
+	DeactivateVanzator(codVanzator int, connections Connections) error
+	GetIstoricVanzator(codVanzator int) ([]repositories.IstoricVanzator, error)
+	GetVanzariVanzator(codVanzator int, dataStart string, dataEnd string) ([]repositories.Vanzare, error)
+
+	GetSucursale() ([]repositories.Sucursala, error)
+	InsertSucursala(sucursalaAdresa repositories.InsertSucursala, connections Connections) error
+
+	GetProiecte() ([]repositories.Proiect, error)
+	GetProiect(IDProiect string) (repositories.Proiect, error)
+	GetLiniiProiect(IDProiect string) ([]repositories.LinieVanzare, error)
+	InsertProiect(proiect repositories.Proiect, connections Connections) error
+
+	GetGrupeArticole() ([]repositories.GrupaArticole, error)
+	GetUnitatiDeMasura() ([]repositories.UnitateDeMasura, error)
+
+	GetLista(table string, params repositories.ListParams) (repositories.PaginaLista, error)
+	Search(termeni []string, limita int) (repositories.RezultateCautare, error)
+
+	GetAgregateRaport(params repositories.RaportParams, coduriParteneri []string) ([]AgregatRaport, error)
+	GetJudeteSucursale() (map[string]string, error)
+	GetVanzariGrupeArticole(params repositories.FormParams, coduriParteneri []string) ([]repositories.VanzariGrupeArticole, error)
+	GetCantitatiJudete(params repositories.FormParams, coduriParteneri []string, judete map[string]string) ([]repositories.CantitateJudete, error)
+	GetProcentDiscountTrimestre(params repositories.FormParams, coduriParteneri []string) ([]repositories.ProcentDiscountTrimestru, error)
+	GetCantitateLivrataZile(params repositories.FormParams, coduriParteneri []string) ([]repositories.CantitateLivrataZile, error)
+	GetFormReport(params repositories.FormParams, coduriParteneri []string) ([]repositories.FormResult, error)
+	GetGroupedFormReport(params repositories.FormParams, coduriParteneri []string) ([]repositories.FormResult, error)
+}
@@ -0,0 +1,155 @@
+package datasources
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"modbSalesApp/src/repositories"
+)
+
+// ValidationError lists every invalid field of a request body; Camp is the
+// JSON path of the field, such as Partener.CUI.
+type ValidationError struct {
+	Erori []repositories.FieldError
+}
+
+func (e ValidationError) Error() string {
+	messages := make([]string, len(e.Erori))
+	for i, fieldErr := range e.Erori {
+		messages[i] = fmt.Sprintf("%s: %s", fieldErr.Camp, fieldErr.Mesaj)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationError) Unwrap() error {
+	return ErrInvalidFields
+}
+
+func (e *ValidationError) add(camp string, format string, args ...interface{}) {
+	e.Erori = append(e.Erori, repositories.FieldError{Camp: camp, Mesaj: fmt.Sprintf(format, args...)})
+}
+
+// judeteRomania are the 41 counties and Bucuresti, without diacritics.
+var judeteRomania = []string{
+	"Alba", "Arad", "Arges", "Bacau", "Bihor", "Bistrita-Nasaud", "Botosani", "Braila", "Brasov", "Buzau",
+	"Calarasi", "Caras-Severin", "Cluj", "Constanta", "Covasna", "Dambovita", "Dolj", "Galati", "Giurgiu", "Gorj",
+	"Harghita", "Hunedoara", "Ialomita", "Iasi", "Ilfov", "Maramures", "Mehedinti", "Mures", "Neamt", "Olt",
+	"Prahova", "Salaj", "Satu Mare", "Sibiu", "Suceava", "Teleorman", "Timis", "Tulcea", "Valcea", "Vaslui",
+	"Vrancea", judetBucuresti,
+}
+
+const judetBucuresti = "Bucuresti"
+
+// cuiControlKey weighs the digits of a CUI, control digit excluded, aligned to
+// the right.
+const cuiControlKey = "753217532"
+
+var diacriticsReplacer = strings.NewReplacer(
+	"ă", "a", "â", "a", "î", "i", "ș", "s", "ş", "s", "ț", "t", "ţ", "t",
+	"Ă", "A", "Â", "A", "Î", "I", "Ș", "S", "Ş", "S", "Ț", "T", "Ţ", "T",
+)
+
+// ValidatePartener checks the CUI, Email and address of a partner and returns
+// a ValidationError listing every invalid field. CUI and Email are optional.
+func ValidatePartener(partenerAdresa repositories.InsertPartener) error {
+	var validationErr ValidationError
+
+	partener := partenerAdresa.Partener
+	if len(strings.TrimSpace(partener.CUI)) > 0 {
+		mesaj := getCUIError(partener.CUI)
+		if len(mesaj) > 0 {
+			validationErr.add("Partener.CUI", "%s", mesaj)
+		}
+	}
+	if len(strings.TrimSpace(partener.Email)) > 0 && !isEmail(partener.Email) {
+		validationErr.add("Partener.Email", "%q is not a valid email address", partener.Email)
+	}
+	validateAdresa(&validationErr, "Adresa", partenerAdresa.Adresa)
+
+	if len(validationErr.Erori) > 0 {
+		return validationErr
+	}
+
+	return nil
+}
+
+// getCUIError checks a Romanian CUI (CIF), with an optional RO prefix for VAT
+// payers, and returns what is wrong with it, or "" when it is valid.
+func getCUIError(cui string) string {
+	value := strings.ToUpper(strings.TrimSpace(cui))
+	digitsStart := strings.IndexFunc(value, func(r rune) bool { return r < 'A' || r > 'Z' })
+	if digitsStart < 0 {
+		digitsStart = len(value)
+	}
+	if prefix := value[:digitsStart]; len(prefix) > 0 && prefix != "RO" {
+		return fmt.Sprintf("prefix %q is not RO", prefix)
+	}
+
+	digits := strings.TrimSpace(value[digitsStart:])
+	if len(digits) < 2 || len(digits) > 10 || strings.IndexFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return "must have 2 to 10 digits, optionally after the RO prefix"
+	}
+
+	body := digits[:len(digits)-1]
+	key := cuiControlKey[len(cuiControlKey)-len(body):]
+	sum := 0
+	for i := range body {
+		sum += int(body[i]-'0') * int(key[i]-'0')
+	}
+	control := sum * 10 % 11
+	if control == 10 {
+		control = 0
+	}
+	if int(digits[len(digits)-1]-'0') != control {
+		return fmt.Sprintf("control digit is %c, expected %d", digits[len(digits)-1], control)
+	}
+
+	return ""
+}
+
+func isEmail(email string) bool {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != strings.TrimSpace(email) {
+		return false
+	}
+
+	return strings.Contains(address.Address[strings.LastIndex(address.Address, "@"):], ".")
+}
+
+// validateAdresa checks Judet against the counties of Romania, ignoring case
+// and diacritics, and Sector, which is 1 to 6 and only given for Bucuresti.
+func validateAdresa(validationErr *ValidationError, camp string, adresa repositories.Adresa) {
+	judet, ok := getJudet(adresa.Judet)
+	if !ok {
+		validationErr.add(camp+".Judet", "%q is not a county of Romania or Bucuresti", adresa.Judet)
+	}
+
+	sector := strings.TrimSpace(adresa.Sector)
+	switch {
+	case judet == judetBucuresti && len(sector) == 0:
+		validationErr.add(camp+".Sector", "is required for Bucuresti")
+	case judet == judetBucuresti && (len(sector) != 1 || sector < "1" || sector > "6"):
+		validationErr.add(camp+".Sector", "%q is not a sector of Bucuresti, 1 to 6", adresa.Sector)
+	case ok && judet != judetBucuresti && len(sector) > 0:
+		validationErr.add(camp+".Sector", "is only given for Bucuresti")
+	}
+}
+
+func getJudet(name string) (string, bool) {
+	normalized := normalizeJudet(name)
+	for _, judet := range judeteRomania {
+		if normalizeJudet(judet) == normalized {
+			return judet, true
+		}
+	}
+
+	return "", false
+}
+
+func normalizeJudet(name string) string {
+	name = strings.ToLower(diacriticsReplacer.Replace(strings.TrimSpace(name)))
+
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool { return r == ' ' || r == '-' }), " ")
+}
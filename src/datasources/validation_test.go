@@ -0,0 +1,73 @@
+package datasources
+
+import "testing"
+
+func TestGetCUIError(t *testing.T) {
+	tests := []struct {
+		cui  string
+		want string
+	}{
+		{cui: "18547290", want: ""},
+		{cui: "RO18547290", want: ""},
+		{cui: "12345674", want: ""},
+		{cui: "123456789", want: ""},
+		{cui: "19", want: ""},
+		// a remainder of 10 gives the control digit 0
+		{cui: "10000130", want: ""},
+		{cui: "RO 18547290", want: ""},
+		{cui: "  RO   18547290 ", want: ""},
+		{cui: "ro18547290", want: ""},
+		{cui: "Ro 18547290", want: ""},
+		{cui: "18547291", want: "control digit is 1, expected 0"},
+		{cui: "RO12345675", want: "control digit is 5, expected 4"},
+		{cui: "ro 123456780", want: "control digit is 0, expected 9"},
+		{cui: "10000131", want: "control digit is 1, expected 0"},
+		{cui: "RX18547290", want: `prefix "RX" is not RO`},
+		{cui: "1", want: "must have 2 to 10 digits, optionally after the RO prefix"},
+		{cui: "RO", want: "must have 2 to 10 digits, optionally after the RO prefix"},
+		{cui: "12345678901", want: "must have 2 to 10 digits, optionally after the RO prefix"},
+		{cui: "RO 1854 7290", want: "must have 2 to 10 digits, optionally after the RO prefix"},
+	}
+
+	for _, test := range tests {
+		if got := getCUIError(test.cui); got != test.want {
+			t.Errorf("getCUIError(%q) = %q, want %q", test.cui, got, test.want)
+		}
+	}
+}
+
+func TestGetJudet(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOk bool
+	}{
+		{name: "Cluj", want: "Cluj", wantOk: true},
+		{name: "cluj", want: "Cluj", wantOk: true},
+		{name: " IASI ", want: "Iasi", wantOk: true},
+		{name: "Iași", want: "Iasi", wantOk: true},
+		{name: "Constanţa", want: "Constanta", wantOk: true},
+		{name: "bistrita nasaud", want: "Bistrita-Nasaud", wantOk: true},
+		{name: "Satu-Mare", want: "Satu Mare", wantOk: true},
+		{name: "București", want: judetBucuresti, wantOk: true},
+		{name: "Sector 1"},
+		{name: "Chisinau"},
+		{name: ""},
+	}
+
+	for _, test := range tests {
+		got, ok := getJudet(test.name)
+		if got != test.want || ok != test.wantOk {
+			t.Errorf("getJudet(%q) = %q, %t, want %q, %t", test.name, got, ok, test.want, test.wantOk)
+		}
+	}
+
+	if len(judeteRomania) != 42 {
+		t.Errorf("judeteRomania has %d entries, want the 41 counties and Bucuresti", len(judeteRomania))
+	}
+	for _, judet := range judeteRomania {
+		if got, ok := getJudet(judet); !ok || got != judet {
+			t.Errorf("getJudet(%q) = %q, %t, want the county itself", judet, got, ok)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package datasources
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"modbSalesApp/src/repositories"
+)
+
+// Columns sales can be sorted by. Every order ends with "IdIntrare", so a
+// cursor points to a single sale.
+const (
+	SortareIDIntrare = "IdIntrare"
+	SortareData      = "Data"
+	SortareTotal     = "Total"
+)
+
+const maxVanzariPageSize = 100
+
+// VanzariCursor is the sale a page of sales starts after; with Inapoi the page
+// holds the sales before it. Sortare and Descrescator tie the cursor to the
+// order it was made for and Valoare is the sort column of the sale.
+type VanzariCursor struct {
+	Sortare      string `json:"s"`
+	Descrescator bool   `json:"d"`
+	Inapoi       bool   `json:"b,omitempty"`
+	Valoare      string `json:"v,omitempty"`
+	IDIntrare    int    `json:"i"`
+}
+
+// VanzareSortata is a sale with the value of the column it is sorted by, as
+// kept in cursors.
+type VanzareSortata struct {
+	repositories.Vanzare
+	Cheie string
+}
+
+// GetPaginaVanzari reads a page of the sales of connections, merged in the
+// order of params, with cursors to the pages around it and the number of
+// sales matching the filters.
+func GetPaginaVanzari(connections Connections, params repositories.VanzariParams) (repositories.PaginaVanzari, error) {
+	err := checkVanzariParams(&params)
+	if err != nil {
+		return repositories.PaginaVanzari{}, err
+	}
+	cursor, err := decodeVanzariCursor(params)
+	if err != nil {
+		return repositories.PaginaVanzari{}, err
+	}
+
+	sites := make([]string, 0, len(connections))
+	for site := range connections {
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+
+	results := make([][]VanzareSortata, len(sites))
+	counts := make([]int, len(sites))
+	errs := make([]error, len(sites))
+
+	var wg sync.WaitGroup
+	for i, site := range sites {
+		wg.Add(1)
+		go func(i int, client Store) {
+			defer wg.Done()
+			results[i], errs[i] = client.GetVanzari(params, cursor)
+			if errs[i] == nil {
+				counts[i], errs[i] = client.CountVanzari(params)
+			}
+		}(i, connections[site])
+	}
+	wg.Wait()
+
+	var vanzari []VanzareSortata
+	pagina := repositories.PaginaVanzari{Vanzari: []repositories.Vanzare{}}
+	for i, err := range errs {
+		if err != nil {
+			return repositories.PaginaVanzari{}, fmt.Errorf("could not read vanzari from %s: %w", sites[i], err)
+		}
+		vanzari = append(vanzari, results[i]...)
+		pagina.NumarTotal += counts[i]
+	}
+
+	inapoi := cursor != nil && cursor.Inapoi
+	descrescator := params.Descrescator != inapoi
+	sort.Slice(vanzari, func(i, j int) bool {
+		return isVanzareBefore(vanzari[i], vanzari[j], params.Sortare, descrescator)
+	})
+
+	hasMore := len(vanzari) > params.Limita
+	if hasMore {
+		vanzari = vanzari[:params.Limita]
+	}
+	if inapoi {
+		for i, j := 0, len(vanzari)-1; i < j; i, j = i+1, j-1 {
+			vanzari[i], vanzari[j] = vanzari[j], vanzari[i]
+		}
+	}
+	if len(vanzari) == 0 {
+		return pagina, nil
+	}
+
+	for _, vanzare := range vanzari {
+		pagina.Vanzari = append(pagina.Vanzari, vanzare.Vanzare)
+	}
+	if hasMore || inapoi {
+		pagina.CursorUrmator = encodeVanzariCursor(params, vanzari[len(vanzari)-1], false)
+	}
+	if (hasMore && inapoi) || (cursor != nil && !inapoi) {
+		pagina.CursorAnterior = encodeVanzariCursor(params, vanzari[0], true)
+	}
+
+	return pagina, nil
+}
+
+// checkVanzariParams rejects unknown sort columns, statuses and dates that
+// are not MM/DD/YYYY, and gives Limita its default.
+func checkVanzariParams(params *repositories.VanzariParams) error {
+	if params.Sortare != SortareIDIntrare && params.Sortare != SortareData && params.Sortare != SortareTotal {
+		return fmt.Errorf("vanzari cannot be sorted by '%s', only by %s, %s or %s: %w", params.Sortare, SortareIDIntrare, SortareData, SortareTotal, ErrInvalidQuery)
+	}
+	if params.Limita == 0 {
+		params.Limita = vanzariPageSize
+	}
+	if params.Limita < 0 || params.Limita > maxVanzariPageSize {
+		return fmt.Errorf("Limita must be between 1 and %d: %w", maxVanzariPageSize, ErrInvalidQuery)
+	}
+	if len(params.Status) > 0 && !IsStatusVanzare(params.Status) {
+		return fmt.Errorf("unknown Status '%s': %w", params.Status, ErrInvalidQuery)
+	}
+
+	dates := [][2]string{
+		{"DataStart", params.DataStart},
+		{"DataEnd", params.DataEnd},
+		{"DataLivrareStart", params.DataLivrareStart},
+		{"DataLivrareEnd", params.DataLivrareEnd},
+	}
+	for _, date := range dates {
+		if len(date[1]) == 0 {
+			continue
+		}
+		_, err := time.Parse("01/02/2006", date[1])
+		if err != nil {
+			return fmt.Errorf("%s '%s' is not a MM/DD/YYYY date: %w", date[0], date[1], ErrInvalidQuery)
+		}
+	}
+
+	return nil
+}
+
+func encodeVanzariCursor(params repositories.VanzariParams, vanzare VanzareSortata, inapoi bool) string {
+	content, _ := json.Marshal(VanzariCursor{
+		Sortare:      params.Sortare,
+		Descrescator: params.Descrescator,
+		Inapoi:       inapoi,
+		Valoare:      vanzare.Cheie,
+		IDIntrare:    vanzare.IDIntrare,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func decodeVanzariCursor(params repositories.VanzariParams) (*VanzariCursor, error) {
+	if len(params.Cursor) == 0 {
+		return nil, nil
+	}
+
+	var cursor VanzariCursor
+	content, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+	if err == nil {
+		err = json.Unmarshal(content, &cursor)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cursor '%s' is not valid: %w", params.Cursor, ErrInvalidQuery)
+	}
+	if cursor.Sortare != params.Sortare || cursor.Descrescator != params.Descrescator {
+		return nil, fmt.Errorf("Cursor was made for another Sortare, send the same Sortare with it: %w", ErrInvalidQuery)
+	}
+
+	return &cursor, nil
+}
+
+// getVanzariCursorFilter keeps the sales after cursor in the given direction,
+// comparing IdIntrare when the sort column is equal.
+func getVanzariCursorFilter(cursor VanzariCursor, descrescator bool) (sqlFilter, error) {
+	var filter sqlFilter
+
+	operator := ">"
+	if descrescator {
+		operator = "<"
+	}
+
+	switch cursor.Sortare {
+	case SortareData:
+		filter.where(
+			fmt.Sprintf(`("Data" %[1]s TO_DATE(?, 'YYYY-MM-DD HH24:MI:SS') OR ("Data" = TO_DATE(?, 'YYYY-MM-DD HH24:MI:SS') AND "IdIntrare" %[1]s ?))`, operator),
+			cursor.Valoare, cursor.Valoare, cursor.IDIntrare,
+		)
+	case SortareTotal:
+		total, err := repositories.ParseMoney(cursor.Valoare)
+		if err != nil {
+			return sqlFilter{}, fmt.Errorf("Cursor is not valid: %w", ErrInvalidQuery)
+		}
+		filter.where(
+			fmt.Sprintf(`(NVL("Total", 0) %[1]s CAST(? AS NUMBER) OR (NVL("Total", 0) = CAST(? AS NUMBER) AND "IdIntrare" %[1]s ?))`, operator),
+			total, total, cursor.IDIntrare,
+		)
+	default:
+		filter.where(fmt.Sprintf(`"IdIntrare" %s ?`, operator), cursor.IDIntrare)
+	}
+
+	return filter, nil
+}
+
+func getVanzariOrderBy(sortare string, descrescator bool) string {
+	direction := "ASC"
+	if descrescator {
+		direction = "DESC"
+	}
+
+	switch sortare {
+	case SortareData:
+		return fmt.Sprintf(`"Data" %[1]s, "IdIntrare" %[1]s`, direction)
+	case SortareTotal:
+		return fmt.Sprintf(`NVL("Total", 0) %[1]s, "IdIntrare" %[1]s`, direction)
+	default:
+		return fmt.Sprintf(`"IdIntrare" %s`, direction)
+	}
+}
+
+// isVanzareBefore orders the sales read from several sites the way each site
+// ordered its own.
+func isVanzareBefore(a VanzareSortata, b VanzareSortata, sortare string, descrescator bool) bool {
+	var comparison int
+	switch sortare {
+	case SortareData:
+		comparison = strings.Compare(a.Cheie, b.Cheie)
+	case SortareTotal:
+		comparison = a.Total.Rat().Cmp(b.Total.Rat())
+	}
+	if comparison == 0 {
+		comparison = a.IDIntrare - b.IDIntrare
+	}
+
+	if descrescator {
+		return comparison > 0
+	}
+
+	return comparison < 0
+}
@@ -0,0 +1,226 @@
+package datasources
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"modbSalesApp/src/repositories"
+)
+
+// VanzatorActiv and VanzatorInactiv mark, in "Activ", a salesperson that
+// sells and one that left.
+const (
+	VanzatorActiv   = "Y"
+	VanzatorInactiv = "N"
+)
+
+// istoricStartDate starts the history of salespeople added before
+// "IstoricVanzatori" was kept, so their earlier sales use their first rate.
+const istoricStartDate = "01/01/1900"
+
+func getDataInceput(vanzatorAdresa repositories.InsertVanzator) string {
+	if len(vanzatorAdresa.DataInceput) > 0 {
+		return vanzatorAdresa.DataInceput
+	}
+
+	return time.Now().Format("01/02/2006")
+}
+
+// Vanzatori and their Adrese are fragmented vertically like Parteneri, so a
+// salesperson is written on global and on every fragment in one distributed
+// transaction; "IstoricVanzatori" only exists on global.
+
+func (client DBClient) InsertVanzator(vanzatorAdresa repositories.InsertVanzator, connections Connections) error {
+	codVanzator, err := nextID(vanzatoriSequence)
+	if err != nil {
+		return err
+	}
+	IDAdresa, err := nextID(adreseSequence)
+	if err != nil {
+		return err
+	}
+
+	vanzator := vanzatorAdresa.Vanzator
+	vanzator.CodVanzator = codVanzator
+	vanzator.IDAdresa = IDAdresa
+	vanzator.Activ = VanzatorActiv
+	adresa := vanzatorAdresa.Adresa
+	adresa.IDAdresa = IDAdresa
+
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		err := forEachReplica(tx, connections, "Adrese", func(client DBClient, q execer) error {
+			return client.insertFragmentRow(q, "Adrese", getAdresaFields(&adresa))
+		})
+		if err != nil {
+			return err
+		}
+
+		err = forEachReplica(tx, connections, "Vanzatori", func(client DBClient, q execer) error {
+			return client.insertFragmentRow(q, "Vanzatori", getVanzatorFields(&vanzator))
+		})
+		if err != nil {
+			return err
+		}
+
+		global, q, err := tx.enlist(connections[GlobalConnectionName])
+		if err != nil {
+			return err
+		}
+
+		return global.insertIstoricVanzator(q, vanzator, getDataInceput(vanzatorAdresa))
+	})
+}
+
+// EditVanzator updates the salesperson and the address it points to. A new
+// SalariuBaza or Comision is kept in "IstoricVanzatori" from DataInceput on
+// instead of overwriting the one in effect before; the fragments get the ones
+// in effect today.
+func (client DBClient) EditVanzator(vanzatorAdresa repositories.InsertVanzator, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		global, q, err := tx.enlist(connections[GlobalConnectionName])
+		if err != nil {
+			return err
+		}
+
+		vanzator := vanzatorAdresa.Vanzator
+		IDAdresa, err := global.getIDAdresaVanzator(q, vanzator.CodVanzator)
+		if err != nil {
+			return err
+		}
+		vanzator.IDAdresa = IDAdresa
+		adresa := vanzatorAdresa.Adresa
+		adresa.IDAdresa = IDAdresa
+
+		today, found, err := global.editIstoricVanzator(q, vanzator, getDataInceput(vanzatorAdresa))
+		if err != nil {
+			return err
+		}
+
+		fields := getVanzatorFields(&vanzator)
+		delete(fields, "Activ")
+		if found {
+			vanzator.SalariuBaza = today.SalariuBaza
+			vanzator.Comision = today.Comision
+		} else {
+			delete(fields, "SalariuBaza")
+			delete(fields, "Comision")
+		}
+
+		err = forEachReplica(tx, connections, "Vanzatori", func(client DBClient, q execer) error {
+			return client.updateFragmentRow(q, "Vanzatori", fields)
+		})
+		if err != nil {
+			return err
+		}
+
+		return forEachReplica(tx, connections, "Adrese", func(client DBClient, q execer) error {
+			return client.updateFragmentRow(q, "Adrese", getAdresaFields(&adresa))
+		})
+	})
+}
+
+// DeactivateVanzator marks the salesperson as inactive on global and on the
+// fragments holding "Activ".
+func (client DBClient) DeactivateVanzator(codVanzator int, connections Connections) error {
+	return runDistributedTransaction(func(tx *distributedTransaction) error {
+		global, q, err := tx.enlist(connections[GlobalConnectionName])
+		if err != nil {
+			return err
+		}
+
+		_, err = global.getIDAdresaVanzator(q, codVanzator)
+		if err != nil {
+			return err
+		}
+
+		vanzator := map[string]interface{}{"CodVanzator": codVanzator, "Activ": VanzatorInactiv}
+		return forEachReplica(tx, connections, "Vanzatori", func(client DBClient, q execer) error {
+			return client.updateFragmentRow(q, "Vanzatori", vanzator)
+		})
+	})
+}
+
+// GetComisioaneVanzator computes the commission of every sale of the
+// salesperson between dataStart and dataEnd with the Comision, a percentage of
+// the sale Total, in effect on the day of the sale.
+func GetComisioaneVanzator(connections Connections, codVanzator int, dataStart string, dataEnd string) ([]repositories.ComisionVanzare, error) {
+	global := connections[GlobalConnectionName]
+
+	istoric, err := global.GetIstoricVanzator(codVanzator)
+	if err != nil {
+		return nil, err
+	}
+	if len(istoric) == 0 {
+		vanzator, err := global.GetVanzator(codVanzator)
+		if err != nil {
+			return nil, err
+		}
+		istoric = []repositories.IstoricVanzator{{CodVanzator: codVanzator, DataInceput: istoricStartDate, SalariuBaza: vanzator.SalariuBaza, Comision: vanzator.Comision}}
+	}
+
+	var vanzari []repositories.Vanzare
+	for _, site := range getRoutedSites() {
+		client, ok := connections[site]
+		if !ok {
+			return nil, fmt.Errorf("no connection configured for site %s", site)
+		}
+
+		siteVanzari, err := client.GetVanzariVanzator(codVanzator, dataStart, dataEnd)
+		if err != nil {
+			return nil, fmt.Errorf("could not get vanzari from %s: %w", site, err)
+		}
+		vanzari = append(vanzari, siteVanzari...)
+	}
+	sort.Slice(vanzari, func(i, j int) bool {
+		return vanzari[i].IDIntrare < vanzari[j].IDIntrare
+	})
+
+	comisioane := make([]repositories.ComisionVanzare, 0, len(vanzari))
+	for _, vanzare := range vanzari {
+		comision, err := getComisionAt(istoric, vanzare.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		valoare, err := vanzare.Total.Mul(new(big.Rat).Quo(getRat(comision), big.NewRat(100, 1)), 2)
+		if err != nil {
+			return nil, err
+		}
+
+		comisioane = append(comisioane, repositories.ComisionVanzare{
+			IDIntrare:       vanzare.IDIntrare,
+			Data:            vanzare.Data,
+			Total:           vanzare.Total,
+			Comision:        comision,
+			ValoareComision: valoare,
+		})
+	}
+
+	return comisioane, nil
+}
+
+// getComisionAt returns the Comision of the last period of istoric, ordered by
+// DataInceput, that started on or before data; sales older than the history
+// use its first period.
+func getComisionAt(istoric []repositories.IstoricVanzator, data string) (float32, error) {
+	dataVanzare, err := time.Parse("01/02/2006", data)
+	if err != nil {
+		return 0, err
+	}
+
+	comision := istoric[0].Comision
+	for _, perioada := range istoric {
+		dataInceput, err := time.Parse("01/02/2006", perioada.DataInceput)
+		if err != nil {
+			return 0, err
+		}
+		if dataInceput.After(dataVanzare) {
+			break
+		}
+		comision = perioada.Comision
+	}
+
+	return comision, nil
+}
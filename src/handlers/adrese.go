@@ -25,7 +25,13 @@ func HandleAdrese(w http.ResponseWriter, r *http.Request, connections datasource
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getAdrese(db, logger)
+		if hasListParameters(r) {
+			response, status, err = getLista(r, db, "Adrese", logger)
+		} else if isReconstructedReadMode(r) {
+			response, status, err = getReconstructedAdrese(connections, logger)
+		} else {
+			response, status, err = getAdrese(db, logger)
+		}
 	case http.MethodPost:
 		status, err = insertAdresa(r, connections[datasources.GlobalConnectionName], logger)
 	default:
@@ -57,7 +63,7 @@ func HandleAdrese(w http.ResponseWriter, r *http.Request, connections datasource
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getAdrese(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+func getAdrese(db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	articole, err := db.GetAdrese()
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
@@ -72,6 +78,21 @@ func getAdrese(db datasources.DBClient, logger *log.Logger) ([]byte, int, error)
 	return response, http.StatusOK, nil
 }
 
+func getReconstructedAdrese(connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	adrese, err := datasources.ReconstructAdrese(connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not reconstruct adrese")
+	}
+
+	response, err := json.Marshal(adrese)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal adrese response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
 func extractAdresaParams(r *http.Request) (repositories.Adresa, error) {
 	var unmarshalledAdresa repositories.Adresa
 
@@ -88,7 +109,7 @@ func extractAdresaParams(r *http.Request) (repositories.Adresa, error) {
 	return unmarshalledAdresa, nil
 }
 
-func insertAdresa(r *http.Request, db datasources.DBClient, logger *log.Logger) (int, error) {
+func insertAdresa(r *http.Request, db datasources.Store, logger *log.Logger) (int, error) {
 	adresa, err := extractAdresaParams(r)
 	if err != nil {
 		return http.StatusBadRequest, errors.New("adresa information sent on request body does not match required format")
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -17,6 +18,7 @@ func HandleArticole(w http.ResponseWriter, r *http.Request, connections datasour
 	var err error
 
 	db := getDatabase(r, connections)
+	codArticol := getPathParameter(r, "/articole")
 
 	switch r.Method {
 	case http.MethodOptions:
@@ -25,9 +27,17 @@ func HandleArticole(w http.ResponseWriter, r *http.Request, connections datasour
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getArticole(db, logger)
+		if hasListParameters(r) {
+			response, status, err = getLista(r, db, "Articole", logger)
+		} else {
+			response, status, err = getArticole(db, logger)
+		}
 	case http.MethodPost:
-		status, err = insertArticol(r, db, logger)
+		status, err = insertArticol(r, connections, logger)
+	case http.MethodPut:
+		status, err = editArticol(r, codArticol, connections, logger)
+	case http.MethodDelete:
+		status, err = deleteArticol(codArticol, connections, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /articole route")
@@ -57,7 +67,7 @@ func HandleArticole(w http.ResponseWriter, r *http.Request, connections datasour
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getArticole(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+func getArticole(db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	articole, err := db.GetArticole()
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
@@ -88,16 +98,53 @@ func extractArticolParams(r *http.Request) (repositories.Articol, error) {
 	return unmarshalledArticol, nil
 }
 
-func insertArticol(r *http.Request, db datasources.DBClient, logger *log.Logger) (int, error) {
+func insertArticol(r *http.Request, connections datasources.Connections, logger *log.Logger) (int, error) {
 	articol, err := extractArticolParams(r)
 	if err != nil {
 		return http.StatusBadRequest, errors.New("articol information sent on request body does not match required format")
 	}
 
-	err = db.InsertArticol(articol)
+	err = connections[datasources.GlobalConnectionName].InsertArticol(articol, connections)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
-		return http.StatusInternalServerError, errors.New("could not save articol")
+		return getErrorStatus(err), errors.New("could not save articol")
+	}
+
+	return http.StatusOK, nil
+}
+
+func editArticol(r *http.Request, codArticol string, connections datasources.Connections, logger *log.Logger) (int, error) {
+	if len(codArticol) == 0 {
+		return http.StatusBadRequest, errors.New("CodArticol must be given in the path: /articole/{CodArticol}")
+	}
+
+	articol, err := extractArticolParams(r)
+	if err != nil {
+		return http.StatusBadRequest, errors.New("articol information sent on request body does not match required format")
+	}
+	articol.CodArticol = codArticol
+
+	err = connections[datasources.GlobalConnectionName].EditArticol(articol, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return getErrorStatus(err), fmt.Errorf("could not update articol %s", codArticol)
+	}
+
+	return http.StatusOK, nil
+}
+
+func deleteArticol(codArticol string, connections datasources.Connections, logger *log.Logger) (int, error) {
+	if len(codArticol) == 0 {
+		return http.StatusBadRequest, errors.New("CodArticol must be given in the path: /articole/{CodArticol}")
+	}
+
+	err := connections[datasources.GlobalConnectionName].DeleteArticol(codArticol, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		if errors.Is(err, datasources.ErrReferenced) {
+			return http.StatusConflict, fmt.Errorf("articol %s is still used by linii de vanzare and cannot be deleted", codArticol)
+		}
+		return getErrorStatus(err), fmt.Errorf("could not delete articol %s", codArticol)
 	}
 
 	return http.StatusOK, nil
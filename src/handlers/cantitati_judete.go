@@ -15,8 +15,6 @@ func HandleCantitatiJudete(w http.ResponseWriter, r *http.Request, connections d
 	var status int
 	var err error
 
-	db := getDatabase(r, connections)
-
 	switch r.Method {
 	case http.MethodOptions:
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -24,7 +22,7 @@ func HandleCantitatiJudete(w http.ResponseWriter, r *http.Request, connections d
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getCantitatiJudete(db, logger)
+		response, status, err = getCantitatiJudete(connections, r, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /cantitatiJudete route")
@@ -54,8 +52,19 @@ func HandleCantitatiJudete(w http.ResponseWriter, r *http.Request, connections d
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getCantitatiJudete(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
-	articole, err := db.GetCantitatiJudete()
+func getCantitatiJudete(connections datasources.Connections, r *http.Request, logger *log.Logger) ([]byte, int, error) {
+	formParams, err := getFormParams(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	sites, err := getReportSites(r, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not get report sites")
+	}
+
+	articole, err := datasources.GetCantitatiJudete(connections, sites, formParams)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
 		return nil, http.StatusInternalServerError, errors.New("could not get cantitatiJudete")
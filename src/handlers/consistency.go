@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"modbSalesApp/src/datasources"
+)
+
+func HandleConsistency(w http.ResponseWriter, r *http.Request, connections datasources.Connections, logger *log.Logger) {
+	var response []byte
+	var status int
+	var err error
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
+		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
+	case http.MethodGet:
+		response, status, err = checkConsistency(r, connections, logger)
+	default:
+		status = http.StatusBadRequest
+		err = errors.New("wrong method type for /admin/consistency route")
+	}
+
+	if err != nil {
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		status = http.StatusInternalServerError
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	status = http.StatusOK
+	logger.Printf("Status: %d %s", status, http.StatusText(status))
+}
+
+func checkConsistency(r *http.Request, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	repair, _ := getStringParameter(r, "repair", false)
+
+	report, err := datasources.CheckConsistency(connections, repair == "true")
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not check fragment consistency")
+	}
+
+	response, err := json.Marshal(report)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal consistency response json")
+	}
+
+	return response, http.StatusOK, nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"modbSalesApp/src/datasources"
+	"modbSalesApp/src/repositories"
+)
+
+func getErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, datasources.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, datasources.ErrInvalidQuery):
+		return http.StatusBadRequest
+	case errors.Is(err, datasources.ErrReferenced), errors.Is(err, datasources.ErrInsufficientStock),
+		errors.Is(err, datasources.ErrVanzareAnulata), errors.Is(err, datasources.ErrInvalidTransition):
+		return http.StatusConflict
+	case errors.Is(err, datasources.ErrPriceMismatch), errors.Is(err, datasources.ErrInvalidCantitate),
+		errors.Is(err, datasources.ErrInvalidProiect), errors.Is(err, datasources.ErrInvalidFields),
+		errors.Is(err, repositories.ErrMoneyOverflow):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError sends a ValidationError as the JSON list of its fields and any
+// other error as text.
+func writeError(w http.ResponseWriter, err error, status int) {
+	var validationErr datasources.ValidationError
+	if !errors.As(err, &validationErr) {
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	response, _ := json.Marshal(repositories.ValidationErrors{Erori: validationErr.Erori})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(response)
+}
+
+// getFormatError names the field of a JSON body holding a value of the wrong
+// type; any other decoding error is reported as message.
+func getFormatError(err error, message string) error {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || len(typeErr.Field) == 0 {
+		return errors.New(message)
+	}
+
+	return datasources.ValidationError{Erori: []repositories.FieldError{{
+		Camp:  typeErr.Field,
+		Mesaj: fmt.Sprintf("expected %s, got %s", typeErr.Type.String(), typeErr.Value),
+	}}}
+}
@@ -15,8 +15,6 @@ func HandleFormReport(w http.ResponseWriter, r *http.Request, connections dataso
 	var status int
 	var err error
 
-	db := getDatabase(r, connections)
-
 	switch r.Method {
 	case http.MethodOptions:
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -24,7 +22,7 @@ func HandleFormReport(w http.ResponseWriter, r *http.Request, connections dataso
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getFormReport(db, r, logger)
+		response, status, err = getFormReport(connections, r, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /formReport route")
@@ -54,13 +52,19 @@ func HandleFormReport(w http.ResponseWriter, r *http.Request, connections dataso
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getFormReport(dw datasources.DBClient, r *http.Request, logger *log.Logger) ([]byte, int, error) {
+func getFormReport(connections datasources.Connections, r *http.Request, logger *log.Logger) ([]byte, int, error) {
 	formParams, err := getFormParams(r)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
 
-	formReport, err := dw.GetFormReport(formParams)
+	sites, err := getReportSites(r, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not get report sites")
+	}
+
+	formReport, err := datasources.GetFormReport(connections, sites, formParams)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
 		return nil, http.StatusInternalServerError, errors.New("could not get formReport")
@@ -54,7 +54,7 @@ func HandleGrupeArticole(w http.ResponseWriter, r *http.Request, connections dat
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getGrupeArticole(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+func getGrupeArticole(db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	grupeArticole, err := db.GetGrupeArticole()
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
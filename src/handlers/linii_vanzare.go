@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -27,9 +28,9 @@ func HandleLiniiVanzari(w http.ResponseWriter, r *http.Request, connections data
 	case http.MethodGet:
 		response, status, err = getLiniiVanzari(r, db, logger)
 	case http.MethodPost, http.MethodPut:
-		status, err = insertLinieVanzare(r, db, logger, r.Method == http.MethodPut)
+		status, err = insertLinieVanzare(r, connections, logger, r.Method == http.MethodPut)
 	case http.MethodDelete:
-		status, err = deleteLinieVanzare(r, db, logger)
+		status, err = deleteLinieVanzare(r, connections, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /liniiVanzari route")
@@ -59,7 +60,7 @@ func HandleLiniiVanzari(w http.ResponseWriter, r *http.Request, connections data
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getLiniiVanzari(r *http.Request, db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+func getLiniiVanzari(r *http.Request, db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	IDIntrare, err := getIntParameter(r, "IDIntrare", true)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
@@ -95,26 +96,40 @@ func extractLinieVanzareParams(r *http.Request) (repositories.LinieVanzare, erro
 	return unmarshalledVanzare, nil
 }
 
-func insertLinieVanzare(r *http.Request, db datasources.DBClient, logger *log.Logger, update bool) (int, error) {
+func insertLinieVanzare(r *http.Request, connections datasources.Connections, logger *log.Logger, update bool) (int, error) {
 	linieVanzare, err := extractLinieVanzareParams(r)
 	if err != nil {
 		return http.StatusBadRequest, errors.New("linieVanzare information sent on request body does not match required format")
 	}
 
-	if update {
-		err = db.EditLinieVanzare(linieVanzare)
-	} else {
-		err = db.InsertLinieVanzare(linieVanzare)
+	err = datasources.PriceLinieVanzare(&linieVanzare, linieVanzare.NumarLinie)
+	if err != nil {
+		return getErrorStatus(err), fmt.Errorf("could not save linieVanzare: %s", err.Error())
+	}
+
+	db, err := datasources.GetVanzareConnection(connections, linieVanzare.IDIntrare)
+	if err == nil {
+		if update {
+			err = db.EditLinieVanzare(linieVanzare, connections)
+		} else {
+			err = db.InsertLinieVanzare(linieVanzare, connections)
+		}
 	}
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
-		return http.StatusInternalServerError, errors.New("could not save linieVanzare")
+
+		status := getErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			return status, errors.New("could not save linieVanzare")
+		}
+
+		return status, fmt.Errorf("could not save linieVanzare: %s", err.Error())
 	}
 
 	return http.StatusOK, nil
 }
 
-func deleteLinieVanzare(r *http.Request, db datasources.DBClient, logger *log.Logger) (int, error) {
+func deleteLinieVanzare(r *http.Request, connections datasources.Connections, logger *log.Logger) (int, error) {
 	IDIntrare, err := getIntParameter(r, "IDIntrare", true)
 	if err != nil {
 		return http.StatusBadRequest, err
@@ -124,10 +139,13 @@ func deleteLinieVanzare(r *http.Request, db datasources.DBClient, logger *log.Lo
 		return http.StatusBadRequest, err
 	}
 
-	err = db.DeleteLinieVanzare(IDIntrare, numarLinie)
+	db, err := datasources.GetVanzareConnection(connections, IDIntrare)
+	if err == nil {
+		err = db.DeleteLinieVanzare(IDIntrare, numarLinie, connections)
+	}
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
-		return http.StatusInternalServerError, errors.New("could not delete linieVanzare")
+		return getErrorStatus(err), errors.New("could not delete linieVanzare")
 	}
 
 	return http.StatusOK, nil
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"modbSalesApp/src/datasources"
+	"modbSalesApp/src/repositories"
+)
+
+// listParameters make a GET on a collection a list query, answered with a page
+// of the collection instead of the whole table.
+var listParameters = []string{"filter", "sort", "fields", "limit", "cursor"}
+
+func hasListParameters(r *http.Request) bool {
+	query := r.URL.Query()
+	for _, name := range listParameters {
+		if _, ok := query[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getListParams reads the list query: filter=Camp:operator:valoare, repeated
+// for several conditions, sort=-Camp1,Camp2, fields=Camp1,Camp2, limit and
+// cursor.
+func getListParams(r *http.Request) (repositories.ListParams, error) {
+	var params repositories.ListParams
+
+	for _, filter := range r.URL.Query()["filter"] {
+		parts := strings.SplitN(filter, ":", 3)
+		if len(parts) != 3 || len(parts[0]) == 0 {
+			return repositories.ListParams{}, fmt.Errorf("filter '%s' does not match the format Camp:operator:valoare", filter)
+		}
+
+		params.Filtre = append(params.Filtre, repositories.FiltruLista{Camp: parts[0], Operator: parts[1], Valoare: parts[2]})
+	}
+
+	sort, _ := getStringParameter(r, "sort", false)
+	for _, camp := range getListFields(sort) {
+		descrescator := strings.HasPrefix(camp, "-")
+		params.Sortare = append(params.Sortare, repositories.SortareLista{
+			Camp:         strings.TrimPrefix(strings.TrimPrefix(camp, "-"), "+"),
+			Descrescator: descrescator,
+		})
+	}
+
+	fields, _ := getStringParameter(r, "fields", false)
+	params.Campuri = getListFields(fields)
+
+	var err error
+	params.Limita, err = getIntParameter(r, "limit", false)
+	if err != nil {
+		return repositories.ListParams{}, err
+	}
+	params.Cursor, _ = getStringParameter(r, "cursor", false)
+
+	return params, nil
+}
+
+func getListFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if len(field) > 0 {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func getLista(r *http.Request, db datasources.Store, table string, logger *log.Logger) ([]byte, int, error) {
+	params, err := getListParams(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	pagina, err := db.GetLista(table, params)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		status := getErrorStatus(err)
+		if status == http.StatusBadRequest {
+			return nil, status, err
+		}
+		return nil, status, fmt.Errorf("could not list %s", strings.ToLower(table))
+	}
+
+	response, err := json.Marshal(pagina)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal list response json")
+	}
+
+	return response, http.StatusOK, nil
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"modbSalesApp/src/datasources"
+	"modbSalesApp/src/repositories"
+)
+
+func HandleMiscariStoc(w http.ResponseWriter, r *http.Request, connections datasources.Connections, logger *log.Logger) {
+	var response []byte
+	var status int
+	var err error
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
+		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
+	case http.MethodGet:
+		response, status, err = getMiscariStoc(r, connections[datasources.GlobalConnectionName], logger)
+	case http.MethodPost:
+		response, status, err = insertMiscareStoc(r, connections, logger)
+	default:
+		status = http.StatusBadRequest
+		err = errors.New("wrong method type for /miscariStoc route")
+	}
+
+	if err != nil {
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	if response == nil {
+		response, _ = json.Marshal(repositories.WasSuccess{Success: true})
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		status = http.StatusInternalServerError
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	status = http.StatusOK
+	logger.Printf("Status: %d %s", status, http.StatusText(status))
+}
+
+func getMiscariStoc(r *http.Request, db datasources.Store, logger *log.Logger) ([]byte, int, error) {
+	codArticol, err := getStringParameter(r, "CodArticol", false)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	miscari, err := db.GetMiscariStoc(codArticol)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not get miscari stoc")
+	}
+
+	response, err := json.Marshal(miscari)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal miscari stoc response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
+func extractMiscareStocParams(r *http.Request) (repositories.MiscareStoc, error) {
+	var unmarshalledMiscare repositories.MiscareStoc
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return repositories.MiscareStoc{}, err
+	}
+
+	err = json.Unmarshal(body, &unmarshalledMiscare)
+	if err != nil {
+		return repositories.MiscareStoc{}, err
+	}
+
+	return unmarshalledMiscare, nil
+}
+
+func insertMiscareStoc(r *http.Request, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	miscare, err := extractMiscareStocParams(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("miscare stoc information sent on request body does not match required format")
+	}
+	if len(miscare.CodArticol) == 0 {
+		return nil, http.StatusBadRequest, errors.New("CodArticol is mandatory")
+	}
+	if !datasources.IsMotivMiscareStoc(miscare.Motiv) {
+		return nil, http.StatusBadRequest, fmt.Errorf(
+			"Motiv must be one of %s, %s, %s",
+			datasources.MotivInventar, datasources.MotivDeteriorare, datasources.MotivReceptie,
+		)
+	}
+	if miscare.Cantitate < 0 || (miscare.Cantitate == 0 && miscare.Motiv != datasources.MotivInventar) {
+		return nil, http.StatusBadRequest, errors.New("Cantitate must be positive, or zero for an inventar")
+	}
+
+	saved, err := connections[datasources.GlobalConnectionName].InsertMiscareStoc(miscare, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		if errors.Is(err, datasources.ErrInsufficientStock) {
+			return nil, http.StatusConflict, fmt.Errorf("articol %s does not have enough stock", miscare.CodArticol)
+		}
+		return nil, getErrorStatus(err), fmt.Errorf("could not save miscare stoc for articol %s", miscare.CodArticol)
+	}
+
+	response, err := json.Marshal(saved)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal miscare stoc response json")
+	}
+
+	return response, http.StatusOK, nil
+}
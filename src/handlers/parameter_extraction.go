@@ -9,7 +9,19 @@ import (
 	"modbSalesApp/src/datasources"
 )
 
-func getDatabase(r *http.Request, connections datasources.Connections) datasources.DBClient {
+const reconstructedReadMode = "reconstructed"
+
+func isReconstructedReadMode(r *http.Request) bool {
+	mode, _ := getStringParameter(r, "mode", false)
+	return mode == reconstructedReadMode
+}
+
+func hasDatabaseParameter(r *http.Request) bool {
+	db, _ := getStringParameter(r, "dbConnection", false)
+	return len(db) > 0
+}
+
+func getDatabase(r *http.Request, connections datasources.Connections) datasources.Store {
 	db, _ := getStringParameter(r, "dbConnection", true)
 	if connection, ok := connections[db]; ok {
 		return connection
@@ -18,6 +30,36 @@ func getDatabase(r *http.Request, connections datasources.Connections) datasourc
 	return connections[datasources.GlobalConnectionName]
 }
 
+// getReportSites returns the dbConnection site or, when none is given, every
+// local site holding sales.
+func getReportSites(r *http.Request, connections datasources.Connections) ([]datasources.Store, error) {
+	if hasDatabaseParameter(r) {
+		return []datasources.Store{getDatabase(r, connections)}, nil
+	}
+
+	return datasources.GetRoutedConnections(connections)
+}
+
+// getPathParameter returns what follows route in the request path, such as the
+// key in /parteneri/{CodPartener}.
+func getPathParameter(r *http.Request, route string) string {
+	return strings.Trim(strings.TrimPrefix(r.URL.Path, route), "/")
+}
+
+func getIntPathParameter(r *http.Request, route string) (int, error) {
+	stringParam := getPathParameter(r, route)
+	if len(stringParam) == 0 {
+		return 0, nil
+	}
+
+	param, err := strconv.Atoi(stringParam)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert path parameter '%s' to integer", stringParam)
+	}
+
+	return param, nil
+}
+
 func getIntParameter(r *http.Request, paramName string, isMandatory bool) (int, error) {
 	stringParam, err := getStringParameter(r, paramName, isMandatory)
 	if err != nil {
@@ -46,9 +88,25 @@ func getStringParameter(r *http.Request, paramName string, isMandatory bool) (st
 		return "", err
 	}
 
-	param := strings.Replace(params[0], `'`, ``, -1)
-	param = strings.Replace(param, `"`, ``, -1)
+	param := strings.Replace(params[0], `"`, ``, -1)
 	param = strings.Replace(param, `”`, ``, -1)
 
 	return param, nil
 }
+
+// getSortParameter reads the parameter name, a field name with a leading - for
+// descending order, such as Sortare=-Total; without it the order is
+// defaultSort.
+func getSortParameter(r *http.Request, name string, defaultSort string) (string, bool) {
+	sort, _ := getStringParameter(r, name, false)
+	sort = strings.TrimSpace(sort)
+	if len(sort) == 0 {
+		sort = defaultSort
+	}
+
+	if strings.HasPrefix(sort, "-") {
+		return sort[1:], true
+	}
+
+	return strings.TrimPrefix(sort, "+"), false
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -17,6 +18,7 @@ func HandleParteneri(w http.ResponseWriter, r *http.Request, connections datasou
 	var err error
 
 	db := getDatabase(r, connections)
+	codPartener := getPathParameter(r, "/parteneri")
 
 	switch r.Method {
 	case http.MethodOptions:
@@ -25,9 +27,21 @@ func HandleParteneri(w http.ResponseWriter, r *http.Request, connections datasou
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getParteneri(db, logger)
+		if len(codPartener) > 0 {
+			response, status, err = getPartener(db, codPartener, logger)
+		} else if hasListParameters(r) {
+			response, status, err = getLista(r, db, "Parteneri", logger)
+		} else if isReconstructedReadMode(r) {
+			response, status, err = getReconstructedParteneri(connections, logger)
+		} else {
+			response, status, err = getParteneri(db, logger)
+		}
 	case http.MethodPost:
-		status, err = insertPartener(r, connections[datasources.GlobalConnectionName], logger)
+		status, err = insertPartener(r, connections, logger)
+	case http.MethodPut:
+		status, err = editPartener(r, codPartener, connections, logger)
+	case http.MethodDelete:
+		status, err = deletePartener(codPartener, connections, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /parteneri route")
@@ -35,7 +49,7 @@ func HandleParteneri(w http.ResponseWriter, r *http.Request, connections datasou
 
 	if err != nil {
 		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
-		http.Error(w, err.Error(), status)
+		writeError(w, err, status)
 
 		return
 	}
@@ -57,7 +71,7 @@ func HandleParteneri(w http.ResponseWriter, r *http.Request, connections datasou
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getParteneri(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+func getParteneri(db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	parteneri, err := db.GetParteneri()
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
@@ -72,6 +86,36 @@ func getParteneri(db datasources.DBClient, logger *log.Logger) ([]byte, int, err
 	return response, http.StatusOK, nil
 }
 
+func getPartener(db datasources.Store, codPartener string, logger *log.Logger) ([]byte, int, error) {
+	partener, err := db.GetPartener(codPartener)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, getErrorStatus(err), fmt.Errorf("could not get partener %s", codPartener)
+	}
+
+	response, err := json.Marshal(partener)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal partener response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
+func getReconstructedParteneri(connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	parteneri, err := datasources.ReconstructParteneri(connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not reconstruct parteneri")
+	}
+
+	response, err := json.Marshal(parteneri)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal parteneri response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
 func extractPartenerParams(r *http.Request) (repositories.InsertPartener, error) {
 	var unmarshalledPartenerAdresa repositories.InsertPartener
 
@@ -88,13 +132,18 @@ func extractPartenerParams(r *http.Request) (repositories.InsertPartener, error)
 	return unmarshalledPartenerAdresa, nil
 }
 
-func insertPartener(r *http.Request, db datasources.DBClient, logger *log.Logger) (int, error) {
+func insertPartener(r *http.Request, connections datasources.Connections, logger *log.Logger) (int, error) {
 	partenerAdresa, err := extractPartenerParams(r)
 	if err != nil {
-		return http.StatusBadRequest, errors.New("partener information sent on request body does not match required format")
+		return http.StatusBadRequest, getFormatError(err, "partener information sent on request body does not match required format")
+	}
+
+	err = datasources.ValidatePartener(partenerAdresa)
+	if err != nil {
+		return getErrorStatus(err), err
 	}
 
-	err = db.InsertPartener(partenerAdresa)
+	err = connections[datasources.GlobalConnectionName].InsertPartener(partenerAdresa, connections)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
 		return http.StatusInternalServerError, errors.New("could not save partener")
@@ -102,3 +151,45 @@ func insertPartener(r *http.Request, db datasources.DBClient, logger *log.Logger
 
 	return http.StatusOK, nil
 }
+
+func editPartener(r *http.Request, codPartener string, connections datasources.Connections, logger *log.Logger) (int, error) {
+	if len(codPartener) == 0 {
+		return http.StatusBadRequest, errors.New("CodPartener must be given in the path: /parteneri/{CodPartener}")
+	}
+
+	partenerAdresa, err := extractPartenerParams(r)
+	if err != nil {
+		return http.StatusBadRequest, getFormatError(err, "partener information sent on request body does not match required format")
+	}
+	partenerAdresa.Partener.CodPartener = codPartener
+
+	err = datasources.ValidatePartener(partenerAdresa)
+	if err != nil {
+		return getErrorStatus(err), err
+	}
+
+	err = connections[datasources.GlobalConnectionName].EditPartener(partenerAdresa, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return getErrorStatus(err), fmt.Errorf("could not update partener %s", codPartener)
+	}
+
+	return http.StatusOK, nil
+}
+
+func deletePartener(codPartener string, connections datasources.Connections, logger *log.Logger) (int, error) {
+	if len(codPartener) == 0 {
+		return http.StatusBadRequest, errors.New("CodPartener must be given in the path: /parteneri/{CodPartener}")
+	}
+
+	err := connections[datasources.GlobalConnectionName].DeletePartener(codPartener, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		if errors.Is(err, datasources.ErrReferenced) {
+			return http.StatusConflict, fmt.Errorf("partener %s is still used by vanzari and cannot be deleted", codPartener)
+		}
+		return getErrorStatus(err), fmt.Errorf("could not delete partener %s", codPartener)
+	}
+
+	return http.StatusOK, nil
+}
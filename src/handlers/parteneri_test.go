@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"modbSalesApp/src/datasources"
+	"modbSalesApp/src/repositories"
+)
+
+// fakeStore keeps partners in memory; the Store methods it does not override
+// panic through the nil embedded interface.
+type fakeStore struct {
+	datasources.Store
+	parteneri map[string]repositories.InsertPartener
+	vanzari   map[string]int
+}
+
+func newFakeConnections(store *fakeStore) datasources.Connections {
+	return datasources.Connections{datasources.GlobalConnectionName: store}
+}
+
+func (store *fakeStore) GetPartener(codPartener string) (repositories.Partener, error) {
+	partenerAdresa, ok := store.parteneri[codPartener]
+	if !ok {
+		return repositories.Partener{}, fmt.Errorf("partener %s: %w", codPartener, datasources.ErrNotFound)
+	}
+
+	return partenerAdresa.Partener, nil
+}
+
+func (store *fakeStore) InsertPartener(partenerAdresa repositories.InsertPartener, connections datasources.Connections) error {
+	store.parteneri[partenerAdresa.Partener.CodPartener] = partenerAdresa
+
+	return nil
+}
+
+func (store *fakeStore) EditPartener(partenerAdresa repositories.InsertPartener, connections datasources.Connections) error {
+	if _, ok := store.parteneri[partenerAdresa.Partener.CodPartener]; !ok {
+		return fmt.Errorf("partener %s: %w", partenerAdresa.Partener.CodPartener, datasources.ErrNotFound)
+	}
+	store.parteneri[partenerAdresa.Partener.CodPartener] = partenerAdresa
+
+	return nil
+}
+
+func (store *fakeStore) DeletePartener(codPartener string, connections datasources.Connections) error {
+	if _, ok := store.parteneri[codPartener]; !ok {
+		return fmt.Errorf("partener %s: %w", codPartener, datasources.ErrNotFound)
+	}
+	if store.vanzari[codPartener] > 0 {
+		return fmt.Errorf("partener %s is used by %d vanzari: %w", codPartener, store.vanzari[codPartener], datasources.ErrReferenced)
+	}
+	delete(store.parteneri, codPartener)
+
+	return nil
+}
+
+const partenerBody = `{
+	"Partener": {"CodPartener": "p2", "NumePartener": "Firma", "CUI": "RO18547290", "Email": "office@firma.ro"},
+	"Adresa": {"NumeAdresa": "Sediu", "Oras": "Iasi", "Judet": "Iasi", "Strada": "Lunga", "Numar": "3", "Etaj": 1}
+}`
+
+func TestHandleParteneri(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "get", method: http.MethodGet, path: "/parteneri/p1", wantStatus: http.StatusOK, wantBody: `"NumePartener":"Client"`},
+		{name: "get missing", method: http.MethodGet, path: "/parteneri/p9", wantStatus: http.StatusNotFound},
+		{name: "insert", method: http.MethodPost, path: "/parteneri", body: partenerBody, wantStatus: http.StatusOK, wantBody: `"success":true`},
+		{name: "insert invalid CUI", method: http.MethodPost, path: "/parteneri", body: strings.Replace(partenerBody, "RO18547290", "RO18547291", 1), wantStatus: http.StatusUnprocessableEntity, wantBody: `"Camp":"Partener.CUI"`},
+		{name: "insert wrong type", method: http.MethodPost, path: "/parteneri", body: strings.Replace(partenerBody, `"Etaj": 1`, `"Etaj": "1"`, 1), wantStatus: http.StatusBadRequest},
+		{name: "edit", method: http.MethodPut, path: "/parteneri/p1", body: partenerBody, wantStatus: http.StatusOK},
+		{name: "edit missing", method: http.MethodPut, path: "/parteneri/p9", body: partenerBody, wantStatus: http.StatusNotFound},
+		{name: "delete referenced", method: http.MethodDelete, path: "/parteneri/p1", wantStatus: http.StatusConflict},
+		{name: "delete", method: http.MethodDelete, path: "/parteneri/p3", wantStatus: http.StatusOK},
+		{name: "delete without key", method: http.MethodDelete, path: "/parteneri", wantStatus: http.StatusBadRequest},
+	}
+
+	logger := log.New(ioutil.Discard, "", 0)
+	for _, test := range tests {
+		store := &fakeStore{
+			parteneri: map[string]repositories.InsertPartener{
+				"p1": {Partener: repositories.Partener{CodPartener: "p1", NumePartener: "Client"}},
+				"p3": {Partener: repositories.Partener{CodPartener: "p3", NumePartener: "Fara vanzari"}},
+			},
+			vanzari: map[string]int{"p1": 2},
+		}
+
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		HandleParteneri(w, r, newFakeConnections(store), logger)
+
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: status %d, want %d; body %s", test.name, w.Code, test.wantStatus, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("%s: body %s, want it to contain %s", test.name, w.Body.String(), test.wantBody)
+		}
+	}
+}
+
+func TestHandleParteneriSavesBody(t *testing.T) {
+	store := &fakeStore{parteneri: map[string]repositories.InsertPartener{}}
+
+	r := httptest.NewRequest(http.MethodPost, "/parteneri", strings.NewReader(partenerBody))
+	w := httptest.NewRecorder()
+	HandleParteneri(w, r, newFakeConnections(store), log.New(ioutil.Discard, "", 0))
+
+	var want repositories.InsertPartener
+	if err := json.Unmarshal([]byte(partenerBody), &want); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.parteneri["p2"]; got != want {
+		t.Errorf("saved %+v, want %+v", got, want)
+	}
+}
@@ -3,20 +3,33 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 
 	"modbSalesApp/src/datasources"
 	"modbSalesApp/src/repositories"
 )
 
+const vanzariSubresource = "vanzari"
+
 func HandleProiecte(w http.ResponseWriter, r *http.Request, connections datasources.Connections, logger *log.Logger) {
 	var response []byte
 	var status int
 	var err error
 
 	db := getDatabase(r, connections)
+	IDProiect, subresource := getProiectPath(r)
+	if len(subresource) > 0 && subresource != vanzariSubresource {
+		status = http.StatusBadRequest
+		err = fmt.Errorf("unknown path /proiecte/%s/%s", IDProiect, subresource)
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
 
 	switch r.Method {
 	case http.MethodOptions:
@@ -25,9 +38,21 @@ func HandleProiecte(w http.ResponseWriter, r *http.Request, connections datasour
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getProiecte(db, logger)
+		if subresource == vanzariSubresource {
+			response, status, err = getVanzariProiect(IDProiect, connections, logger)
+		} else if len(IDProiect) > 0 {
+			status, err = http.StatusBadRequest, errors.New("wrong method type for this /proiecte route")
+		} else if hasListParameters(r) {
+			response, status, err = getLista(r, db, "Proiecte", logger)
+		} else {
+			response, status, err = getProiecte(db, logger)
+		}
 	case http.MethodPost:
-		status, err = insertProiect(r, db, logger)
+		if len(IDProiect) > 0 {
+			status, err = http.StatusBadRequest, errors.New("wrong method type for this /proiecte route")
+		} else {
+			status, err = insertProiect(r, connections, logger)
+		}
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /proiecte route")
@@ -57,7 +82,18 @@ func HandleProiecte(w http.ResponseWriter, r *http.Request, connections datasour
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getProiecte(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+// getProiectPath splits /proiecte/{IDProiect}/{vanzari}; both parts are
+// optional.
+func getProiectPath(r *http.Request) (string, string) {
+	parts := strings.SplitN(getPathParameter(r, "/proiecte"), "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+func getProiecte(db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	proiecte, err := db.GetProiecte()
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
@@ -88,13 +124,13 @@ func extractProiectParams(r *http.Request) (repositories.Proiect, error) {
 	return unmarshalledProiect, nil
 }
 
-func insertProiect(r *http.Request, db datasources.DBClient, logger *log.Logger) (int, error) {
+func insertProiect(r *http.Request, connections datasources.Connections, logger *log.Logger) (int, error) {
 	proiect, err := extractProiectParams(r)
 	if err != nil {
 		return http.StatusBadRequest, errors.New("proiect information sent on request body does not match required format")
 	}
 
-	err = db.InsertProiect(proiect)
+	err = connections[datasources.GlobalConnectionName].InsertProiect(proiect, connections)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
 		return http.StatusInternalServerError, errors.New("could not save proiect")
@@ -102,3 +138,18 @@ func insertProiect(r *http.Request, db datasources.DBClient, logger *log.Logger)
 
 	return http.StatusOK, nil
 }
+
+func getVanzariProiect(IDProiect string, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	vanzari, err := datasources.GetVanzariProiect(connections, IDProiect)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, getErrorStatus(err), fmt.Errorf("could not get vanzari for proiect %s", IDProiect)
+	}
+
+	response, err := json.Marshal(vanzari)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal proiect vanzari response json")
+	}
+
+	return response, http.StatusOK, nil
+}
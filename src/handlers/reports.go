@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"modbSalesApp/src/datasources"
+	"modbSalesApp/src/repositories"
+)
+
+func HandleReportQuery(w http.ResponseWriter, r *http.Request, connections datasources.Connections, logger *log.Logger) {
+	var response []byte
+	var status int
+	var err error
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
+		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
+	case http.MethodGet:
+		response, status, err = getReportQuery(r, connections, logger)
+	default:
+		status = http.StatusBadRequest
+		err = errors.New("wrong method type for /reports/query route")
+	}
+
+	if err != nil {
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	if response == nil {
+		response, _ = json.Marshal(repositories.WasSuccess{Success: true})
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		status = http.StatusInternalServerError
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	status = http.StatusOK
+	logger.Printf("Status: %d %s", status, http.StatusText(status))
+}
+
+// getReportQuery reads dimensions=grupa,month and measures=platit,vanzari,
+// both comma separated, and the filters of the report form.
+func getReportQuery(r *http.Request, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	measures, err := getStringParameter(r, "measures", true)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	dimensions, _ := getStringParameter(r, "dimensions", false)
+	formParams, err := getFormParams(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	raport, err := datasources.GetRaport(connections, repositories.RaportParams{
+		Dimensiuni: getListFields(dimensions),
+		Masuri:     getListFields(measures),
+		Filtre:     formParams,
+	})
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		status := getErrorStatus(err)
+		if status == http.StatusBadRequest {
+			return nil, status, err
+		}
+		return nil, status, errors.New("could not get report")
+	}
+
+	response, err := json.Marshal(raport)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal report response json")
+	}
+
+	return response, http.StatusOK, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"modbSalesApp/src/datasources"
+	"modbSalesApp/src/repositories"
+)
+
+func HandleSearch(w http.ResponseWriter, r *http.Request, connections datasources.Connections, logger *log.Logger) {
+	var response []byte
+	var status int
+	var err error
+
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
+		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
+	case http.MethodGet:
+		response, status, err = search(r, connections, logger)
+	default:
+		status = http.StatusBadRequest
+		err = errors.New("wrong method type for /search route")
+	}
+
+	if err != nil {
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	if response == nil {
+		response, _ = json.Marshal(repositories.WasSuccess{Success: true})
+	}
+
+	_, err = w.Write(response)
+	if err != nil {
+		status = http.StatusInternalServerError
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
+
+	status = http.StatusOK
+	logger.Printf("Status: %d %s", status, http.StatusText(status))
+}
+
+func search(r *http.Request, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	query, err := getStringParameter(r, "q", true)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	limita, err := getIntParameter(r, "limit", false)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	rezultate, err := datasources.Search(connections, query, limita)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		status := getErrorStatus(err)
+		if status == http.StatusBadRequest {
+			return nil, status, err
+		}
+		return nil, status, errors.New("could not search")
+	}
+
+	response, err := json.Marshal(rezultate)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal search response json")
+	}
+
+	return response, http.StatusOK, nil
+}
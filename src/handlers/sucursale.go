@@ -25,9 +25,13 @@ func HandleSucursale(w http.ResponseWriter, r *http.Request, connections datasou
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getSucursale(db, logger)
+		if hasListParameters(r) {
+			response, status, err = getLista(r, db, "Sucursale", logger)
+		} else {
+			response, status, err = getSucursale(db, logger)
+		}
 	case http.MethodPost:
-		status, err = insertSucursala(r, db, connections[datasources.GlobalConnectionName], logger)
+		status, err = insertSucursala(r, connections, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /sucursale route")
@@ -57,7 +61,7 @@ func HandleSucursale(w http.ResponseWriter, r *http.Request, connections datasou
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getSucursale(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+func getSucursale(db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	sucursale, err := db.GetSucursale()
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
@@ -88,13 +92,13 @@ func extractSucursalaParams(r *http.Request) (repositories.InsertSucursala, erro
 	return unmarshalledSucursala, nil
 }
 
-func insertSucursala(r *http.Request, db datasources.DBClient, global datasources.DBClient, logger *log.Logger) (int, error) {
+func insertSucursala(r *http.Request, connections datasources.Connections, logger *log.Logger) (int, error) {
 	sucursala, err := extractSucursalaParams(r)
 	if err != nil {
 		return http.StatusBadRequest, errors.New("sucursala information sent on request body does not match required format")
 	}
 
-	err = db.InsertSucursala(sucursala, global)
+	err = connections[datasources.GlobalConnectionName].InsertSucursala(sucursala, connections)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
 		return http.StatusInternalServerError, errors.New("could not save sucursala")
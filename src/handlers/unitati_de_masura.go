@@ -54,7 +54,7 @@ func HandleUnitatiDeMasura(w http.ResponseWriter, r *http.Request, connections d
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getUnitatiDeMasura(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+func getUnitatiDeMasura(db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	unitatiDeMasura, err := db.GetUnitatiDeMasura()
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
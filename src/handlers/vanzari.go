@@ -3,20 +3,33 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"modbSalesApp/src/datasources"
 	"modbSalesApp/src/repositories"
 )
 
+const transitionSubresource = "transition"
+
 func HandleVanzari(w http.ResponseWriter, r *http.Request, connections datasources.Connections, logger *log.Logger) {
 	var response []byte
 	var status int
 	var err error
 
 	db := getDatabase(r, connections)
+	IDIntrare, subresource, err := getVanzarePath(r)
+	if err != nil {
+		status = http.StatusBadRequest
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
 
 	switch r.Method {
 	case http.MethodOptions:
@@ -25,9 +38,37 @@ func HandleVanzari(w http.ResponseWriter, r *http.Request, connections datasourc
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getVanzari(db, logger)
+		if subresource == istoricSubresource {
+			response, status, err = getIstoricStatusVanzare(IDIntrare, connections, logger)
+		} else if subresource == transitionSubresource {
+			status, err = http.StatusBadRequest, errors.New("wrong method type for /vanzari/{IDIntrare}/transition route")
+		} else if IDIntrare != 0 {
+			response, status, err = getVanzare(IDIntrare, connections, logger)
+		} else if hasDatabaseParameter(r) {
+			response, status, err = getVanzari(r, db, logger)
+		} else {
+			response, status, err = getVanzariFromAllSites(r, connections, logger)
+		}
 	case http.MethodPost:
-		status, err = insertVanzare(r, db, connections[datasources.GlobalConnectionName], logger)
+		if subresource == transitionSubresource {
+			response, status, err = transitionVanzare(r, IDIntrare, connections, logger)
+		} else if len(subresource) > 0 || IDIntrare != 0 {
+			status, err = http.StatusBadRequest, errors.New("wrong method type for this /vanzari route")
+		} else {
+			status, err = insertVanzare(r, connections, logger)
+		}
+	case http.MethodPut:
+		if len(subresource) > 0 {
+			status, err = http.StatusBadRequest, errors.New("wrong method type for this /vanzari route")
+		} else {
+			status, err = editVanzare(r, IDIntrare, connections, logger)
+		}
+	case http.MethodDelete:
+		if len(subresource) > 0 {
+			status, err = http.StatusBadRequest, errors.New("wrong method type for this /vanzari route")
+		} else {
+			status, err = cancelVanzare(r, IDIntrare, connections, logger)
+		}
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /vanzari route")
@@ -57,11 +98,37 @@ func HandleVanzari(w http.ResponseWriter, r *http.Request, connections datasourc
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getVanzari(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
-	vanzari, err := db.GetVanzari()
+func getVanzari(r *http.Request, db datasources.Store, logger *log.Logger) ([]byte, int, error) {
+	params, err := getVanzariParams(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	site, _ := getStringParameter(r, "dbConnection", false)
+	vanzari, err := datasources.GetPaginaVanzari(datasources.Connections{site: db}, params)
+
+	return getPaginaVanzariResponse(vanzari, err, logger)
+}
+
+func getVanzariFromAllSites(r *http.Request, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	params, err := getVanzariParams(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	vanzari, err := datasources.GetVanzariFromAllSites(connections, params)
+
+	return getPaginaVanzariResponse(vanzari, err, logger)
+}
+
+func getPaginaVanzariResponse(vanzari repositories.PaginaVanzari, err error, logger *log.Logger) ([]byte, int, error) {
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
-		return nil, http.StatusInternalServerError, errors.New("could not get vanzari")
+		status := getErrorStatus(err)
+		if status == http.StatusBadRequest {
+			return nil, status, err
+		}
+		return nil, status, errors.New("could not get vanzari")
 	}
 
 	response, err := json.Marshal(vanzari)
@@ -72,6 +139,46 @@ func getVanzari(db datasources.DBClient, logger *log.Logger) ([]byte, int, error
 	return response, http.StatusOK, nil
 }
 
+func getVanzariParams(r *http.Request) (repositories.VanzariParams, error) {
+	var (
+		params repositories.VanzariParams
+		err    error
+	)
+
+	stringParams := map[string]*string{
+		"CodPartener":      &params.CodPartener,
+		"Status":           &params.Status,
+		"Moneda":           &params.Moneda,
+		"DataStart":        &params.DataStart,
+		"DataEnd":          &params.DataEnd,
+		"DataLivrareStart": &params.DataLivrareStart,
+		"DataLivrareEnd":   &params.DataLivrareEnd,
+		"Cursor":           &params.Cursor,
+	}
+	for name, value := range stringParams {
+		*value, err = getStringParameter(r, name, false)
+		if err != nil {
+			return repositories.VanzariParams{}, err
+		}
+	}
+
+	params.CodVanzator, err = getIntParameter(r, "CodVanzator", false)
+	if err != nil {
+		return repositories.VanzariParams{}, err
+	}
+	params.IDSucursala, err = getIntParameter(r, "IDSucursala", false)
+	if err != nil {
+		return repositories.VanzariParams{}, err
+	}
+	params.Limita, err = getIntParameter(r, "Limita", false)
+	if err != nil {
+		return repositories.VanzariParams{}, err
+	}
+	params.Sortare, params.Descrescator = getSortParameter(r, "Sortare", "-"+datasources.SortareIDIntrare)
+
+	return params, nil
+}
+
 func extractVanzareParams(r *http.Request) (repositories.InsertVanzare, error) {
 	var unmarshalledvanzare repositories.InsertVanzare
 
@@ -88,17 +195,247 @@ func extractVanzareParams(r *http.Request) (repositories.InsertVanzare, error) {
 	return unmarshalledvanzare, nil
 }
 
-func insertVanzare(r *http.Request, db datasources.DBClient, general datasources.DBClient, logger *log.Logger) (int, error) {
+func insertVanzare(r *http.Request, connections datasources.Connections, logger *log.Logger) (int, error) {
 	vanzare, err := extractVanzareParams(r)
 	if err != nil {
 		return http.StatusBadRequest, errors.New("vanzare information sent on request body does not match required format")
 	}
 
-	err = db.InsertVanzare(vanzare, general)
+	if len(vanzare.Vanzare.Status) == 0 {
+		vanzare.Vanzare.Status = datasources.VanzareCiorna
+	}
+	if vanzare.Vanzare.Status != datasources.VanzareCiorna {
+		return http.StatusBadRequest, fmt.Errorf("a new vanzare starts as %s, not %s", datasources.VanzareCiorna, vanzare.Vanzare.Status)
+	}
+
+	err = datasources.PriceVanzare(&vanzare)
+	if err != nil {
+		return getErrorStatus(err), fmt.Errorf("could not save vanzare: %s", err.Error())
+	}
+
+	db, err := datasources.GetSucursalaConnection(connections, vanzare.Vanzare.IDSucursala)
 	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	vanzator, err := connections[datasources.GlobalConnectionName].GetVanzator(vanzare.Vanzare.CodVanzator)
+	if err != nil && !errors.Is(err, datasources.ErrNotFound) {
 		logger.Printf("Internal error: %s", err.Error())
 		return http.StatusInternalServerError, errors.New("could not save vanzare")
 	}
+	if vanzator.Activ == datasources.VanzatorInactiv {
+		return http.StatusBadRequest, fmt.Errorf("vanzator %d is no longer active", vanzator.CodVanzator)
+	}
+
+	err = db.InsertVanzare(vanzare, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+
+		status := getErrorStatus(err)
+		var linieErr datasources.LinieVanzareError
+		if errors.As(err, &linieErr) {
+			if status == http.StatusInternalServerError {
+				return status, fmt.Errorf("could not save vanzare: linia %d (articol %s) could not be saved", linieErr.Linie, linieErr.CodArticol)
+			}
+
+			return status, fmt.Errorf("could not save vanzare: %s", linieErr.Error())
+		}
+
+		return status, errors.New("could not save vanzare")
+	}
+
+	return http.StatusOK, nil
+}
+
+func getVanzare(IDIntrare int, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	db, err := datasources.GetVanzareConnection(connections, IDIntrare)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, getErrorStatus(err), fmt.Errorf("could not get vanzarea %d", IDIntrare)
+	}
+
+	vanzare, err := db.GetVanzare(IDIntrare)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, getErrorStatus(err), fmt.Errorf("could not get vanzarea %d", IDIntrare)
+	}
+	linii, err := db.GetLiniiVanzare(IDIntrare)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, fmt.Errorf("could not get vanzarea %d", IDIntrare)
+	}
+
+	response, err := json.Marshal(repositories.InsertVanzare{Vanzare: vanzare, LiniiVanzari: linii})
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal vanzare response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
+func extractVanzareHeaderParams(r *http.Request) (repositories.Vanzare, error) {
+	var unmarshalledVanzare repositories.Vanzare
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return repositories.Vanzare{}, err
+	}
+
+	err = json.Unmarshal(body, &unmarshalledVanzare)
+	if err != nil {
+		return repositories.Vanzare{}, err
+	}
+
+	return unmarshalledVanzare, nil
+}
+
+func editVanzare(r *http.Request, IDIntrare int, connections datasources.Connections, logger *log.Logger) (int, error) {
+	if IDIntrare == 0 {
+		return http.StatusBadRequest, errors.New("IDIntrare must be given in the path: /vanzari/{IDIntrare}")
+	}
+
+	vanzare, err := extractVanzareHeaderParams(r)
+	if err != nil {
+		return http.StatusBadRequest, errors.New("vanzare information sent on request body does not match required format")
+	}
+	vanzare.IDIntrare = IDIntrare
+
+	db, err := datasources.GetVanzareConnection(connections, IDIntrare)
+	if err == nil {
+		err = db.EditVanzare(vanzare)
+	}
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		if errors.Is(err, datasources.ErrInvalidTransition) || errors.Is(err, datasources.ErrPriceMismatch) {
+			return getErrorStatus(err), fmt.Errorf("could not update vanzarea %d: %s", IDIntrare, err.Error())
+		}
+
+		return getErrorStatus(err), fmt.Errorf("could not update vanzarea %d", IDIntrare)
+	}
+
+	return http.StatusOK, nil
+}
+
+// cancelVanzare moves the sale to anulata; the optional CodVanzator parameter
+// names who cancelled it.
+func cancelVanzare(r *http.Request, IDIntrare int, connections datasources.Connections, logger *log.Logger) (int, error) {
+	if IDIntrare == 0 {
+		return http.StatusBadRequest, errors.New("IDIntrare must be given in the path: /vanzari/{IDIntrare}")
+	}
+
+	codVanzator, err := getIntParameter(r, "CodVanzator", false)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	_, status, err := changeStatusVanzare(repositories.TranzitieVanzare{IDIntrare: IDIntrare, StatusNou: datasources.VanzareAnulata, CodVanzator: codVanzator}, connections, logger)
+	if err != nil {
+		return status, fmt.Errorf("could not cancel vanzarea %d: %s", IDIntrare, err.Error())
+	}
 
 	return http.StatusOK, nil
 }
+
+// getVanzarePath splits /vanzari/{IDIntrare}/{transition|istoric}; both parts
+// are optional.
+func getVanzarePath(r *http.Request) (int, string, error) {
+	path := getPathParameter(r, "/vanzari")
+	if len(path) == 0 {
+		return 0, "", nil
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	IDIntrare, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", fmt.Errorf("IDIntrare %s in the path is not an integer", parts[0])
+	}
+	if len(parts) == 1 {
+		return IDIntrare, "", nil
+	}
+	if parts[1] != istoricSubresource && parts[1] != transitionSubresource {
+		return 0, "", fmt.Errorf("unknown path /vanzari/%s", path)
+	}
+
+	return IDIntrare, parts[1], nil
+}
+
+func transitionVanzare(r *http.Request, IDIntrare int, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	var tranzitie repositories.TranzitieVanzare
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &tranzitie)
+	}
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("transition information sent on request body does not match required format")
+	}
+	tranzitie.IDIntrare = IDIntrare
+	if !datasources.IsStatusVanzare(tranzitie.StatusNou) {
+		return nil, http.StatusBadRequest, fmt.Errorf("unknown StatusNou %q", tranzitie.StatusNou)
+	}
+
+	tranzitie, status, err := changeStatusVanzare(tranzitie, connections, logger)
+	if err != nil {
+		return nil, status, fmt.Errorf("could not change the status of vanzarea %d: %s", IDIntrare, err.Error())
+	}
+
+	response, err := json.Marshal(tranzitie)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal transition response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
+// changeStatusVanzare checks the vanzator and applies the transition on the
+// site of the sale. Its errors can be shown to the client.
+func changeStatusVanzare(tranzitie repositories.TranzitieVanzare, connections datasources.Connections, logger *log.Logger) (repositories.TranzitieVanzare, int, error) {
+	if tranzitie.CodVanzator != 0 {
+		_, err := connections[datasources.GlobalConnectionName].GetVanzator(tranzitie.CodVanzator)
+		if errors.Is(err, datasources.ErrNotFound) {
+			return repositories.TranzitieVanzare{}, http.StatusBadRequest, fmt.Errorf("vanzator %d does not exist", tranzitie.CodVanzator)
+		}
+		if err != nil {
+			logger.Printf("Internal error: %s", err.Error())
+			return repositories.TranzitieVanzare{}, http.StatusInternalServerError, errors.New("internal error")
+		}
+	}
+
+	db, err := datasources.GetVanzareConnection(connections, tranzitie.IDIntrare)
+	if err == nil {
+		tranzitie, err = db.TransitionVanzare(tranzitie, connections)
+	}
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+
+		status := getErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			return repositories.TranzitieVanzare{}, status, errors.New("internal error")
+		}
+
+		return repositories.TranzitieVanzare{}, status, err
+	}
+
+	return tranzitie, http.StatusOK, nil
+}
+
+func getIstoricStatusVanzare(IDIntrare int, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	db, err := datasources.GetVanzareConnection(connections, IDIntrare)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, getErrorStatus(err), fmt.Errorf("could not get the status history of vanzarea %d", IDIntrare)
+	}
+
+	istoric, err := db.GetIstoricStatusVanzare(IDIntrare)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, fmt.Errorf("could not get the status history of vanzarea %d", IDIntrare)
+	}
+
+	response, err := json.Marshal(istoric)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal status history response json")
+	}
+
+	return response, http.StatusOK, nil
+}
@@ -15,8 +15,6 @@ func HandleVanzariGrupeArticole(w http.ResponseWriter, r *http.Request, connecti
 	var status int
 	var err error
 
-	db := getDatabase(r, connections)
-
 	switch r.Method {
 	case http.MethodOptions:
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -24,7 +22,7 @@ func HandleVanzariGrupeArticole(w http.ResponseWriter, r *http.Request, connecti
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getVanzariGrupeArticole(db, logger)
+		response, status, err = getVanzariGrupeArticole(connections, r, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /vanzariGrupeArticole route")
@@ -54,8 +52,19 @@ func HandleVanzariGrupeArticole(w http.ResponseWriter, r *http.Request, connecti
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getVanzariGrupeArticole(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
-	articole, err := db.GetVanzariGrupeArticole()
+func getVanzariGrupeArticole(connections datasources.Connections, r *http.Request, logger *log.Logger) ([]byte, int, error) {
+	formParams, err := getFormParams(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	sites, err := getReportSites(r, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not get report sites")
+	}
+
+	articole, err := datasources.GetVanzariGrupeArticole(connections, sites, formParams)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
 		return nil, http.StatusInternalServerError, errors.New("could not get vanzariGrupeArticole")
@@ -3,20 +3,36 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"modbSalesApp/src/datasources"
 	"modbSalesApp/src/repositories"
 )
 
+const (
+	istoricSubresource    = "istoric"
+	comisioaneSubresource = "comisioane"
+)
+
 func HandleVanzatori(w http.ResponseWriter, r *http.Request, connections datasources.Connections, logger *log.Logger) {
 	var response []byte
 	var status int
 	var err error
 
 	db := getDatabase(r, connections)
+	codVanzator, subresource, err := getVanzatorPath(r)
+	if err != nil {
+		status = http.StatusBadRequest
+		logger.Printf("Error: %s; Status: %d %s", err.Error(), status, http.StatusText(status))
+		http.Error(w, err.Error(), status)
+
+		return
+	}
 
 	switch r.Method {
 	case http.MethodOptions:
@@ -25,9 +41,25 @@ func HandleVanzatori(w http.ResponseWriter, r *http.Request, connections datasou
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getVanzatori(db, logger)
+		if subresource == istoricSubresource {
+			response, status, err = getIstoricVanzator(codVanzator, connections[datasources.GlobalConnectionName], logger)
+		} else if subresource == comisioaneSubresource {
+			response, status, err = getComisioaneVanzator(r, codVanzator, connections, logger)
+		} else if codVanzator != 0 {
+			response, status, err = getVanzator(codVanzator, db, logger)
+		} else if hasListParameters(r) {
+			response, status, err = getLista(r, db, "Vanzatori", logger)
+		} else if isReconstructedReadMode(r) {
+			response, status, err = getReconstructedVanzatori(connections, logger)
+		} else {
+			response, status, err = getVanzatori(db, logger)
+		}
 	case http.MethodPost:
-		status, err = insertVanzator(r, connections[datasources.GlobalConnectionName], logger)
+		status, err = insertVanzator(r, connections, logger)
+	case http.MethodPut:
+		status, err = editVanzator(r, codVanzator, connections, logger)
+	case http.MethodDelete:
+		status, err = deactivateVanzator(codVanzator, connections, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /vanzatori route")
@@ -57,7 +89,7 @@ func HandleVanzatori(w http.ResponseWriter, r *http.Request, connections datasou
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getVanzatori(db datasources.DBClient, logger *log.Logger) ([]byte, int, error) {
+func getVanzatori(db datasources.Store, logger *log.Logger) ([]byte, int, error) {
 	vanzatori, err := db.GetVanzatori()
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
@@ -72,6 +104,21 @@ func getVanzatori(db datasources.DBClient, logger *log.Logger) ([]byte, int, err
 	return response, http.StatusOK, nil
 }
 
+func getReconstructedVanzatori(connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	vanzatori, err := datasources.ReconstructVanzatori(connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not reconstruct vanzatori")
+	}
+
+	response, err := json.Marshal(vanzatori)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal vanzatori response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
 func extractVanzatorParams(r *http.Request) (repositories.InsertVanzator, error) {
 	var unmarshalledVanzator repositories.InsertVanzator
 
@@ -88,13 +135,13 @@ func extractVanzatorParams(r *http.Request) (repositories.InsertVanzator, error)
 	return unmarshalledVanzator, nil
 }
 
-func insertVanzator(r *http.Request, db datasources.DBClient, logger *log.Logger) (int, error) {
+func insertVanzator(r *http.Request, connections datasources.Connections, logger *log.Logger) (int, error) {
 	vanzator, err := extractVanzatorParams(r)
 	if err != nil {
 		return http.StatusBadRequest, errors.New("vanzator information sent on request body does not match required format")
 	}
 
-	err = db.InsertVanzator(vanzator)
+	err = connections[datasources.GlobalConnectionName].InsertVanzator(vanzator, connections)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
 		return http.StatusInternalServerError, errors.New("could not save vanzator")
@@ -102,3 +149,114 @@ func insertVanzator(r *http.Request, db datasources.DBClient, logger *log.Logger
 
 	return http.StatusOK, nil
 }
+
+// getVanzatorPath splits /vanzatori/{CodVanzator}/{istoric|comisioane}; both
+// parts are optional.
+func getVanzatorPath(r *http.Request) (int, string, error) {
+	path := getPathParameter(r, "/vanzatori")
+	if len(path) == 0 {
+		return 0, "", nil
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	codVanzator, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", fmt.Errorf("CodVanzator %s in the path is not an integer", parts[0])
+	}
+	if len(parts) == 1 {
+		return codVanzator, "", nil
+	}
+	if parts[1] != istoricSubresource && parts[1] != comisioaneSubresource {
+		return 0, "", fmt.Errorf("unknown path /vanzatori/%s", path)
+	}
+
+	return codVanzator, parts[1], nil
+}
+
+func getVanzator(codVanzator int, db datasources.Store, logger *log.Logger) ([]byte, int, error) {
+	vanzator, err := db.GetVanzator(codVanzator)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, getErrorStatus(err), fmt.Errorf("could not get vanzator %d", codVanzator)
+	}
+
+	response, err := json.Marshal(vanzator)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal vanzator response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
+func editVanzator(r *http.Request, codVanzator int, connections datasources.Connections, logger *log.Logger) (int, error) {
+	if codVanzator == 0 {
+		return http.StatusBadRequest, errors.New("CodVanzator must be given in the path: /vanzatori/{CodVanzator}")
+	}
+
+	vanzatorAdresa, err := extractVanzatorParams(r)
+	if err != nil {
+		return http.StatusBadRequest, errors.New("vanzator information sent on request body does not match required format")
+	}
+	vanzatorAdresa.Vanzator.CodVanzator = codVanzator
+
+	err = connections[datasources.GlobalConnectionName].EditVanzator(vanzatorAdresa, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return getErrorStatus(err), fmt.Errorf("could not update vanzator %d", codVanzator)
+	}
+
+	return http.StatusOK, nil
+}
+
+func deactivateVanzator(codVanzator int, connections datasources.Connections, logger *log.Logger) (int, error) {
+	if codVanzator == 0 {
+		return http.StatusBadRequest, errors.New("CodVanzator must be given in the path: /vanzatori/{CodVanzator}")
+	}
+
+	err := connections[datasources.GlobalConnectionName].DeactivateVanzator(codVanzator, connections)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return getErrorStatus(err), fmt.Errorf("could not deactivate vanzator %d", codVanzator)
+	}
+
+	return http.StatusOK, nil
+}
+
+func getIstoricVanzator(codVanzator int, db datasources.Store, logger *log.Logger) ([]byte, int, error) {
+	istoric, err := db.GetIstoricVanzator(codVanzator)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, fmt.Errorf("could not get istoric for vanzator %d", codVanzator)
+	}
+
+	response, err := json.Marshal(istoric)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal istoric response json")
+	}
+
+	return response, http.StatusOK, nil
+}
+
+func getComisioaneVanzator(r *http.Request, codVanzator int, connections datasources.Connections, logger *log.Logger) ([]byte, int, error) {
+	dataStart, err := getStringParameter(r, "DataStart", false)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	dataEnd, err := getStringParameter(r, "DataEnd", false)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	comisioane, err := datasources.GetComisioaneVanzator(connections, codVanzator, dataStart, dataEnd)
+	if err != nil {
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, getErrorStatus(err), fmt.Errorf("could not compute comisioane for vanzator %d", codVanzator)
+	}
+
+	response, err := json.Marshal(comisioane)
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.New("could not marshal comisioane response json")
+	}
+
+	return response, http.StatusOK, nil
+}
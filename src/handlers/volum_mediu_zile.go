@@ -15,8 +15,6 @@ func HandleCantitateMedieZile(w http.ResponseWriter, r *http.Request, connection
 	var status int
 	var err error
 
-	db := getDatabase(r, connections)
-
 	switch r.Method {
 	case http.MethodOptions:
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -24,7 +22,7 @@ func HandleCantitateMedieZile(w http.ResponseWriter, r *http.Request, connection
 		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token")
 		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
 	case http.MethodGet:
-		response, status, err = getCantitateMedieZile(db, r, logger)
+		response, status, err = getCantitateMedieZile(connections, r, logger)
 	default:
 		status = http.StatusBadRequest
 		err = errors.New("wrong method type for /cantitateZile route")
@@ -54,17 +52,19 @@ func HandleCantitateMedieZile(w http.ResponseWriter, r *http.Request, connection
 	logger.Printf("Status: %d %s", status, http.StatusText(status))
 }
 
-func getCantitateMedieZile(db datasources.DBClient, r *http.Request, logger *log.Logger) ([]byte, int, error) {
-	dataStart, err := getStringParameter(r, "DataStart", false)
+func getCantitateMedieZile(connections datasources.Connections, r *http.Request, logger *log.Logger) ([]byte, int, error) {
+	formParams, err := getFormParams(r)
 	if err != nil {
 		return nil, http.StatusBadRequest, err
 	}
-	dataEnd, err := getStringParameter(r, "DataEnd", false)
+
+	sites, err := getReportSites(r, connections)
 	if err != nil {
-		return nil, http.StatusBadRequest, err
+		logger.Printf("Internal error: %s", err.Error())
+		return nil, http.StatusInternalServerError, errors.New("could not get report sites")
 	}
 
-	articole, err := db.GetCantitateLivrataZile(dataStart, dataEnd)
+	articole, err := datasources.GetCantitateLivrataZile(connections, sites, formParams)
 	if err != nil {
 		logger.Printf("Internal error: %s", err.Error())
 		return nil, http.StatusInternalServerError, errors.New("could not get volumLivratZile")
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MoneyScale is the number of decimals a Money keeps; more precise values,
+// such as report averages, are rounded half away from zero.
+const MoneyScale = 4
+
+// Money is an exact decimal amount, kept as a count of 1/10^MoneyScale. It is
+// read from NUMBER columns and from JSON numbers or strings through their
+// decimal text, so 24.54 stays 24.54, and written to JSON as a number with at
+// least 2 decimals.
+type Money struct {
+	units int64
+}
+
+// ErrMoneyOverflow reports an amount or a sum of amounts that does not fit in
+// a Money.
+var ErrMoneyOverflow = errors.New("amount is too large")
+
+var decimalRegexp = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// ParseMoney reads a decimal literal such as 24.54 or -0.5; fractions and
+// exponents are rejected.
+func ParseMoney(text string) (Money, error) {
+	text = strings.TrimSpace(text)
+	if !decimalRegexp.MatchString(text) {
+		return Money{}, fmt.Errorf("%q is not a decimal amount", text)
+	}
+
+	r, _ := new(big.Rat).SetString(text)
+
+	return MoneyFromRat(r)
+}
+
+func MoneyFromRat(value *big.Rat) (Money, error) {
+	return RoundMoney(value, MoneyScale)
+}
+
+// RoundMoney rounds value once to decimals, half away from zero.
+func RoundMoney(value *big.Rat, decimals int) (Money, error) {
+	if decimals > MoneyScale {
+		decimals = MoneyScale
+	}
+
+	units := new(big.Int).Mul(roundRat(value, decimals), getPowerOfTen(MoneyScale-decimals))
+	if !units.IsInt64() {
+		return Money{}, fmt.Errorf("%s: %w", value.FloatString(2), ErrMoneyOverflow)
+	}
+
+	return Money{units: units.Int64()}, nil
+}
+
+func (m Money) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.units), getPowerOfTen(MoneyScale))
+}
+
+// Round rounds m to decimals, half away from zero.
+func (m Money) Round(decimals int) Money {
+	if decimals >= MoneyScale {
+		return m
+	}
+
+	rounded := new(big.Int).Mul(roundRat(m.Rat(), decimals), getPowerOfTen(MoneyScale-decimals))
+
+	return Money{units: rounded.Int64()}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if (other.units > 0 && m.units > math.MaxInt64-other.units) || (other.units < 0 && m.units < math.MinInt64-other.units) {
+		return Money{}, fmt.Errorf("%s + %s: %w", m, other, ErrMoneyOverflow)
+	}
+
+	return Money{units: m.units + other.units}, nil
+}
+
+// Mul returns m * factor rounded once to decimals, half away from zero.
+func (m Money) Mul(factor *big.Rat, decimals int) (Money, error) {
+	return RoundMoney(new(big.Rat).Mul(m.Rat(), factor), decimals)
+}
+
+func (m Money) IsZero() bool {
+	return m.units == 0
+}
+
+func (m Money) Float64() float64 {
+	f, _ := m.Rat().Float64()
+
+	return f
+}
+
+func (m Money) String() string {
+	text := m.Rat().FloatString(MoneyScale)
+	for strings.HasSuffix(text, "0") && len(text)-strings.IndexByte(text, '.') > 3 {
+		text = text[:len(text)-1]
+	}
+
+	return text
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		*m = Money{}
+		return nil
+	}
+
+	parsed, err := ParseMoney(strings.Trim(text, `"`))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+
+	return nil
+}
+
+// Scan reads NUMBER columns, which the drivers hand over as int64, float64 or
+// text; a float64 is read through its shortest decimal form.
+func (m *Money) Scan(src interface{}) error {
+	var err error
+
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+	case int64:
+		*m, err = MoneyFromRat(new(big.Rat).SetInt64(v))
+	case float64:
+		*m, err = ParseMoney(strconv.FormatFloat(v, 'f', -1, 64))
+	case []byte:
+		*m, err = ParseMoney(string(v))
+	case string:
+		*m, err = ParseMoney(v)
+	default:
+		err = fmt.Errorf("cannot scan %T into Money", src)
+	}
+
+	return err
+}
+
+// Value binds the amount as its decimal text, so NUMBER columns receive it
+// exactly; comparisons against an expression rather than a column need the
+// value cast to a number.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// roundRat returns value * 10^decimals rounded to an integer, half away from
+// zero.
+func roundRat(value *big.Rat, decimals int) *big.Int {
+	scaled := new(big.Rat).Mul(value, new(big.Rat).SetInt(getPowerOfTen(decimals)))
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+
+	// |remainder| / denominator >= 1/2
+	doubled := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	if doubled.Cmp(scaled.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(scaled.Sign())))
+	}
+
+	return quotient
+}
+
+func getPowerOfTen(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{text: "24.54", want: "24.54"},
+		{text: " 120.7 ", want: "120.70"},
+		{text: "-0.5", want: "-0.50"},
+		{text: "+3", want: "3.00"},
+		{text: ".25", want: "0.25"},
+		{text: "7.", want: "7.00"},
+		{text: "0.00005", want: "0.0001"},
+		{text: "-0.00005", want: "-0.0001"},
+		{text: "1/3", wantErr: true},
+		{text: "1e3", wantErr: true},
+		{text: "0x10", wantErr: true},
+		{text: "12,5", wantErr: true},
+		{text: "", wantErr: true},
+		{text: "abc", wantErr: true},
+		{text: "1000000000000000000", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseMoney(test.text)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %s, want an error", test.text, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) returned %v", test.text, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("ParseMoney(%q) = %s, want %s", test.text, got, test.want)
+		}
+	}
+}
+
+func TestUnmarshalMoney(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    string
+		wantErr bool
+	}{
+		{json: `24.54`, want: "24.54"},
+		{json: `"24.54"`, want: "24.54"},
+		{json: `null`, want: "0.00"},
+		{json: `"1/3"`, wantErr: true},
+		{json: `1e2`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		var got Money
+		err := got.UnmarshalJSON([]byte(test.json))
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("UnmarshalJSON(%s) = %s, want an error", test.json, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("UnmarshalJSON(%s) returned %v", test.json, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("UnmarshalJSON(%s) = %s, want %s", test.json, got, test.want)
+		}
+	}
+}
+
+func TestRoundMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		decimals int
+		want     string
+	}{
+		{value: "1.005", decimals: 2, want: "1.01"},
+		{value: "-1.005", decimals: 2, want: "-1.01"},
+		{value: "1.0049", decimals: 2, want: "1.00"},
+		{value: "2.5", decimals: 0, want: "3.00"},
+		{value: "-2.5", decimals: 0, want: "-3.00"},
+		{value: "1.23456", decimals: 4, want: "1.2346"},
+		{value: "1.23456", decimals: 6, want: "1.2346"},
+		// rounded once, not first to MoneyScale and then to 2 decimals
+		{value: "0.00495", decimals: 2, want: "0.00"},
+	}
+
+	for _, test := range tests {
+		value, _ := new(big.Rat).SetString(test.value)
+		got, err := RoundMoney(value, test.decimals)
+		if err != nil {
+			t.Errorf("RoundMoney(%s, %d) returned %v", test.value, test.decimals, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("RoundMoney(%s, %d) = %s, want %s", test.value, test.decimals, got, test.want)
+		}
+	}
+}
+
+func TestMoneyRound(t *testing.T) {
+	tests := []struct {
+		money    string
+		decimals int
+		want     string
+	}{
+		{money: "1.005", decimals: 2, want: "1.01"},
+		{money: "-1.005", decimals: 2, want: "-1.01"},
+		{money: "24.5449", decimals: 2, want: "24.54"},
+		{money: "24.545", decimals: 2, want: "24.55"},
+		{money: "0.5", decimals: 0, want: "1.00"},
+		{money: "1.2345", decimals: 4, want: "1.2345"},
+	}
+
+	for _, test := range tests {
+		money, _ := ParseMoney(test.money)
+		if got := money.Round(test.decimals); got.String() != test.want {
+			t.Errorf("%s.Round(%d) = %s, want %s", test.money, test.decimals, got, test.want)
+		}
+	}
+}
+
+func TestMoneyOverflow(t *testing.T) {
+	largest := Money{units: math.MaxInt64}
+	smallest := Money{units: math.MinInt64}
+	one, _ := ParseMoney("0.0001")
+
+	tests := []struct {
+		name string
+		run  func() (Money, error)
+	}{
+		{name: "add above the largest amount", run: func() (Money, error) { return largest.Add(one) }},
+		{name: "add below the smallest amount", run: func() (Money, error) { return smallest.Add(Money{units: -1}) }},
+		{name: "mul above the largest amount", run: func() (Money, error) { return largest.Mul(big.NewRat(2, 1), MoneyScale) }},
+		{name: "mul below the smallest amount", run: func() (Money, error) { return largest.Mul(big.NewRat(-2, 1), MoneyScale) }},
+		{name: "rat above the largest amount", run: func() (Money, error) {
+			return MoneyFromRat(new(big.Rat).SetFrac(new(big.Int).Lsh(big.NewInt(1), 80), big.NewInt(1)))
+		}},
+	}
+
+	for _, test := range tests {
+		_, err := test.run()
+		if !errors.Is(err, ErrMoneyOverflow) {
+			t.Errorf("%s returned %v, want ErrMoneyOverflow", test.name, err)
+		}
+	}
+
+	sum, err := largest.Add(Money{units: -1})
+	if err != nil || sum.units != math.MaxInt64-1 {
+		t.Errorf("largest.Add(-1) = %v, %v, want no overflow", sum.units, err)
+	}
+	product, err := one.Mul(big.NewRat(3, 2), 2)
+	if err != nil || product.String() != "0.00" {
+		t.Errorf("0.0001 * 1.5 rounded to 2 decimals = %s, %v, want 0.00", product, err)
+	}
+}
+
+func TestMoneyValue(t *testing.T) {
+	money, _ := ParseMoney("0.1")
+	value, err := money.Value()
+	if err != nil || value != "0.10" {
+		t.Errorf("Value() = %#v, %v, want \"0.10\"", value, err)
+	}
+}
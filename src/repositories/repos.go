@@ -10,19 +10,19 @@ type (
 	}
 
 	Vanzare struct {
-		IDIntrare   int     `json:"IDIntrare"`
-		CodPartener string  `json:"CodPartener"`
-		Status      string  `json:"Status"`
-		Data        string  `json:"Data"`
-		DataLivrare string  `json:"DataLivrare"`
-		Total       float32 `json:"Total"`
-		VAT         float32 `json:"VAT"`
-		Discount    float32 `json:"Discount"`
-		Moneda      string  `json:"Moneda"`
-		Platit      float32 `json:"Platit"`
-		Comentarii  string  `json:"Comentarii"`
-		CodVanzator int     `json:"CodVanzator"`
-		IDSucursala int     `json:"IDSucursala"`
+		IDIntrare   int    `json:"IDIntrare"`
+		CodPartener string `json:"CodPartener"`
+		Status      string `json:"Status"`
+		Data        string `json:"Data"`
+		DataLivrare string `json:"DataLivrare"`
+		Total       Money  `json:"Total"`
+		VAT         Money  `json:"VAT"`
+		Discount    Money  `json:"Discount"`
+		Moneda      string `json:"Moneda"`
+		Platit      Money  `json:"Platit"`
+		Comentarii  string `json:"Comentarii"`
+		CodVanzator int    `json:"CodVanzator"`
+		IDSucursala int    `json:"IDSucursala"`
 	}
 
 	LinieVanzare struct {
@@ -30,11 +30,13 @@ type (
 		NumarLinie int     `json:"NumarLinie"`
 		CodArticol string  `json:"CodArticol"`
 		Cantitate  float32 `json:"Cantitate"`
-		Pret       float32 `json:"Pret"`
-		Discount   float32 `json:"Discount"`
-		VAT        float32 `json:"VAT"`
-		TotalLinie float32 `json:"TotalLinie"`
+		Pret       Money   `json:"Pret"`
+		Discount   Money   `json:"Discount"`
+		VAT        Money   `json:"VAT"`
+		TotalLinie Money   `json:"TotalLinie"`
 		IDProiect  string  `json:"IDProiect"`
+		// StocScazut tells whether saving the line took its quantity out of stock.
+		StocScazut bool `json:"-"`
 	}
 
 	Proiect struct {
@@ -45,6 +47,14 @@ type (
 		Activ       string `json:"Activ"`
 	}
 
+	VanzariProiect struct {
+		IDProiect  string         `json:"IDProiect"`
+		Venit      Money          `json:"Venit"`
+		Cantitate  float64        `json:"Cantitate"`
+		NumarLinii int            `json:"NumarLinii"`
+		Linii      []LinieVanzare `json:"Linii"`
+	}
+
 	Articol struct {
 		CodArticol      string `json:"CodArticol"`
 		NumeArticol     string `json:"NumeArticol"`
@@ -53,6 +63,26 @@ type (
 		IDUnitateMasura int    `json:"IDUnitateMasura"`
 	}
 
+	TranzitieVanzare struct {
+		IDIntrare      int    `json:"IDIntrare"`
+		NumarTranzitie int    `json:"NumarTranzitie"`
+		StatusVechi    string `json:"StatusVechi"`
+		StatusNou      string `json:"StatusNou"`
+		Data           string `json:"Data"`
+		CodVanzator    int    `json:"CodVanzator"`
+	}
+
+	MiscareStoc struct {
+		IDMiscare     int    `json:"IDMiscare"`
+		CodArticol    string `json:"CodArticol"`
+		Motiv         string `json:"Motiv"`
+		Cantitate     int    `json:"Cantitate"`
+		Diferenta     int    `json:"Diferenta"`
+		CantitateStoc int    `json:"CantitateStoc"`
+		Data          string `json:"Data"`
+		Comentarii    string `json:"Comentarii"`
+	}
+
 	GrupaArticole struct {
 		CodGrupa     int    `json:"CodGrupa"`
 		NumeGrupa    string `json:"NumeGrupa"`
@@ -77,10 +107,26 @@ type (
 		CodVanzator int     `json:"CodVanzator"`
 		Nume        string  `json:"Nume"`
 		Prenume     string  `json:"Prenume"`
-		SalariuBaza float32 `json:"SalariuBaza"`
+		SalariuBaza Money   `json:"SalariuBaza"`
 		Comision    float32 `json:"Comision"`
 		Email       string  `json:"Email"`
 		IDAdresa    int     `json:"IDAdresa"`
+		Activ       string  `json:"Activ"`
+	}
+
+	IstoricVanzator struct {
+		CodVanzator int     `json:"CodVanzator"`
+		DataInceput string  `json:"DataInceput"`
+		SalariuBaza Money   `json:"SalariuBaza"`
+		Comision    float32 `json:"Comision"`
+	}
+
+	ComisionVanzare struct {
+		IDIntrare       int     `json:"IDIntrare"`
+		Data            string  `json:"Data"`
+		Total           Money   `json:"Total"`
+		Comision        float32 `json:"Comision"`
+		ValoareComision Money   `json:"ValoareComision"`
 	}
 
 	Adresa struct {
@@ -95,14 +141,46 @@ type (
 		Etaj       int    `json:"Etaj"`
 	}
 
+	ReconstructedPartener struct {
+		Partener
+		Surse map[string]string `json:"Surse"`
+	}
+
+	ReconstructedAdresa struct {
+		Adresa
+		Surse map[string]string `json:"Surse"`
+	}
+
+	ReconstructedVanzator struct {
+		Vanzator
+		Surse map[string]string `json:"Surse"`
+	}
+
+	FragmentConsistency struct {
+		Tabela        string                   `json:"Tabela"`
+		Site          string                   `json:"Site"`
+		RanduriLipsa  []map[string]interface{} `json:"RanduriLipsa"`
+		RanduriInPlus []map[string]interface{} `json:"RanduriInPlus"`
+		Diferente     []DiferentaColoana       `json:"Diferente"`
+		Reparatii     []string                 `json:"Reparatii,omitempty"`
+	}
+
+	DiferentaColoana struct {
+		Cheie          map[string]interface{} `json:"Cheie"`
+		Coloana        string                 `json:"Coloana"`
+		ValoareGlobala interface{}            `json:"ValoareGlobala"`
+		ValoareLocala  interface{}            `json:"ValoareLocala"`
+	}
+
 	InsertPartener struct {
 		Partener Partener `json:"Partener"`
 		Adresa   Adresa   `json:"Adresa"`
 	}
 
 	InsertVanzator struct {
-		Vanzator Vanzator `json:"Vanzator"`
-		Adresa   Adresa   `json:"Adresa"`
+		Vanzator    Vanzator `json:"Vanzator"`
+		Adresa      Adresa   `json:"Adresa"`
+		DataInceput string   `json:"DataInceput"`
 	}
 
 	InsertSucursala struct {
@@ -119,6 +197,89 @@ type (
 		Success bool `json:"success"`
 	}
 
+	FieldError struct {
+		Camp  string `json:"Camp"`
+		Mesaj string `json:"Mesaj"`
+	}
+
+	ValidationErrors struct {
+		Erori []FieldError `json:"Erori"`
+	}
+
+	VanzariParams struct {
+		CodPartener      string
+		CodVanzator      int
+		IDSucursala      int
+		Status           string
+		Moneda           string
+		DataStart        string
+		DataEnd          string
+		DataLivrareStart string
+		DataLivrareEnd   string
+		Sortare          string
+		Descrescator     bool
+		Limita           int
+		Cursor           string
+	}
+
+	PaginaVanzari struct {
+		Vanzari        []Vanzare `json:"Vanzari"`
+		NumarTotal     int       `json:"NumarTotal"`
+		CursorUrmator  string    `json:"CursorUrmator"`
+		CursorAnterior string    `json:"CursorAnterior"`
+	}
+
+	ListParams struct {
+		Filtre  []FiltruLista
+		Sortare []SortareLista
+		Campuri []string
+		Limita  int
+		Cursor  string
+	}
+
+	FiltruLista struct {
+		Camp     string
+		Operator string
+		Valoare  string
+	}
+
+	SortareLista struct {
+		Camp         string
+		Descrescator bool
+	}
+
+	PaginaLista struct {
+		Rezultate      []map[string]interface{} `json:"Rezultate"`
+		NumarTotal     int                      `json:"NumarTotal"`
+		CursorUrmator  string                   `json:"CursorUrmator"`
+		CursorAnterior string                   `json:"CursorAnterior"`
+	}
+
+	RezultatCautare struct {
+		Cheie    string   `json:"Cheie"`
+		Denumire string   `json:"Denumire"`
+		Campuri  []string `json:"Campuri"`
+		Scor     int      `json:"Scor"`
+	}
+
+	RezultateCautare struct {
+		Parteneri []RezultatCautare `json:"Parteneri"`
+		Articole  []RezultatCautare `json:"Articole"`
+		Vanzatori []RezultatCautare `json:"Vanzatori"`
+		Sucursale []RezultatCautare `json:"Sucursale"`
+	}
+
+	RaportParams struct {
+		Dimensiuni []string
+		Masuri     []string
+		Filtre     FormParams
+	}
+
+	Raport struct {
+		Coloane []string        `json:"Coloane"`
+		Randuri [][]interface{} `json:"Randuri"`
+	}
+
 	FormParams struct {
 		CodVanzator   int
 		NumeArticol   string
@@ -129,17 +290,17 @@ type (
 	}
 
 	FormResult struct {
-		Pret            float32 `json:"Pret"`
-		Cantitate       float32 `json:"Cantitate"`
-		Vat             float32 `json:"VAT"`
-		Discount        float32 `json:"Discount"`
-		Platit          float32 `json:"Platit"`
-		NumarTranzactii float32 `json:"NumarTranzactii"`
+		Pret            Money   `json:"Pret"`
+		Cantitate       float64 `json:"Cantitate"`
+		Vat             Money   `json:"VAT"`
+		Discount        Money   `json:"Discount"`
+		Platit          Money   `json:"Platit"`
+		NumarTranzactii float64 `json:"NumarTranzactii"`
 	}
 
 	VanzariGrupeArticole struct {
-		NumeGrupa     string  `json:"NumeGrupa"`
-		VanzareTotala float32 `json:"VanzareTotala"`
+		NumeGrupa     string `json:"NumeGrupa"`
+		VanzareTotala Money  `json:"VanzareTotala"`
 	}
 
 	CantitateJudete struct {
@@ -151,6 +312,8 @@ type (
 	ProcentDiscountTrimestru struct {
 		Trimestru       string  `json:"Trimestru"`
 		ProcentDiscount float32 `json:"ProcentDiscount"`
+		// NumarVanzari weighs the average when merging the quarters of several sites.
+		NumarVanzari int `json:"-"`
 	}
 
 	CantitateLivrataZile struct {
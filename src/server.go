@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -66,21 +68,46 @@ func newServer(connections datasources.Connections, options ...option) *server {
 			handlers.HandleArticole(w, r, connections, s.logger)
 		},
 	)
+	s.mux.HandleFunc("/articole/",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleArticole(w, r, connections, s.logger)
+		},
+	)
+	s.mux.HandleFunc("/miscariStoc",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleMiscariStoc(w, r, connections, s.logger)
+		},
+	)
 	s.mux.HandleFunc("/parteneri",
 		func(w http.ResponseWriter, r *http.Request) {
 			handlers.HandleParteneri(w, r, connections, s.logger)
 		},
 	)
+	s.mux.HandleFunc("/parteneri/",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleParteneri(w, r, connections, s.logger)
+		},
+	)
 	s.mux.HandleFunc("/vanzatori",
 		func(w http.ResponseWriter, r *http.Request) {
 			handlers.HandleVanzatori(w, r, connections, s.logger)
 		},
 	)
+	s.mux.HandleFunc("/vanzatori/",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleVanzatori(w, r, connections, s.logger)
+		},
+	)
 	s.mux.HandleFunc("/vanzari",
 		func(w http.ResponseWriter, r *http.Request) {
 			handlers.HandleVanzari(w, r, connections, s.logger)
 		},
 	)
+	s.mux.HandleFunc("/vanzari/",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleVanzari(w, r, connections, s.logger)
+		},
+	)
 	s.mux.HandleFunc("/liniiVanzari",
 		func(w http.ResponseWriter, r *http.Request) {
 			handlers.HandleLiniiVanzari(w, r, connections, s.logger)
@@ -96,6 +123,11 @@ func newServer(connections datasources.Connections, options ...option) *server {
 			handlers.HandleProiecte(w, r, connections, s.logger)
 		},
 	)
+	s.mux.HandleFunc("/proiecte/",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleProiecte(w, r, connections, s.logger)
+		},
+	)
 	s.mux.HandleFunc("/grupeArticole",
 		func(w http.ResponseWriter, r *http.Request) {
 			handlers.HandleGrupeArticole(w, r, connections, s.logger)
@@ -136,12 +168,26 @@ func newServer(connections datasources.Connections, options ...option) *server {
 			handlers.HandleCantitateMedieZile(w, r, connections, s.logger)
 		},
 	)
+	s.mux.HandleFunc("/reports/query",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleReportQuery(w, r, connections, s.logger)
+		},
+	)
+	s.mux.HandleFunc("/search",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleSearch(w, r, connections, s.logger)
+		},
+	)
+	s.mux.HandleFunc("/admin/consistency",
+		func(w http.ResponseWriter, r *http.Request) {
+			handlers.HandleConsistency(w, r, connections, s.logger)
+		},
+	)
 
 	return s
 }
 
-func main() {
-	logger := log.New(os.Stdout, "", 0)
+func getOracleConnections() datasources.Connections {
 	ip := "5.12.79.189"
 	connections := make(datasources.Connections, 5)
 	connections[datasources.GlobalConnectionName] = datasources.GetClient("SCHEMA_PROIECT_MODB", "pass1234", fmt.Sprintf("%s:1521", ip), "MS.MSHOME.NET", datasources.GlobalConnectionName)
@@ -149,6 +195,76 @@ func main() {
 	connections[datasources.Local2ConnectionName] = datasources.GetClient("SCHEMA_PROIECT_MODB", "pass1234", fmt.Sprintf("%s:1523", ip), "SLV2", datasources.Local2ConnectionName)
 	connections[datasources.Local3ConnectionName] = datasources.GetClient("SCHEMA_PROIECT_MODB", "pass1234", fmt.Sprintf("%s:1524", ip), "SLV3", datasources.Local3ConnectionName)
 	connections[datasources.Local4ConnectionName] = datasources.GetClient("SCHEMA_PROIECT_MODB", "pass1234", fmt.Sprintf("%s:1525", ip), "SLV4", datasources.Local4ConnectionName)
+
+	return connections
+}
+
+// runConsistencyCommand prints the fragment consistency report as JSON and
+// returns a non zero exit code when a fragment differs from global.
+func runConsistencyCommand(connections datasources.Connections, args []string, logger *log.Logger) int {
+	command := flag.NewFlagSet("consistency", flag.ExitOnError)
+	repair := command.Bool("repair", false, "include the SQL statements that repair each fragment")
+	_ = command.Parse(args)
+
+	report, err := datasources.CheckConsistency(connections, *repair)
+	if err != nil {
+		logger.Printf("Could not check fragment consistency: %s", err.Error())
+		return 2
+	}
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		logger.Printf("Could not marshal consistency report: %s", err.Error())
+		return 2
+	}
+	fmt.Println(string(output))
+
+	if !datasources.IsConsistent(report) {
+		return 1
+	}
+
+	return 0
+}
+
+func main() {
+	sqlitePath := flag.String("sqlite", "", "path to a SQLite database file used instead of the Oracle databases")
+	sucursalePath := flag.String("sucursale", "", "path to a JSON file mapping each IdSucursala to the local site holding its vanzari")
+	transactionLogPath := flag.String("txlog", "transactions.log", "path to the log used to recover distributed transactions after a crash")
+	flag.Parse()
+
+	logger := log.New(os.Stdout, "", 0)
+
+	if len(*sucursalePath) > 0 {
+		err := datasources.LoadSucursalaSites(*sucursalePath)
+		if err != nil {
+			logger.Fatalf("Could not load sucursale routing: %s", err.Error())
+		}
+	}
+
+	var connections datasources.Connections
+	if len(*sqlitePath) > 0 {
+		connections = datasources.GetSQLiteConnections(*sqlitePath)
+	} else {
+		connections = getOracleConnections()
+	}
+
+	if flag.Arg(0) == "consistency" {
+		os.Exit(runConsistencyCommand(connections, flag.Args()[1:], logger))
+	}
+
+	err := datasources.OpenTransactionLog(*transactionLogPath)
+	if err != nil {
+		logger.Fatalf("Could not open transaction log: %s", err.Error())
+	}
+	err = datasources.RecoverTransactions(connections)
+	if err != nil {
+		logger.Fatalf("Could not recover distributed transactions: %s", err.Error())
+	}
+	err = datasources.InitIDAllocator(connections)
+	if err != nil {
+		logger.Fatalf("Could not initialize id allocation: %s", err.Error())
+	}
+
 	hs := setup(logger, connections)
 
 	logger.Printf("Listening on http://localhost%s\n", hs.Addr)